@@ -9,62 +9,195 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/common/encryption"
+	"github.com/royroki/LetsGo/internal/common/health"
 	"github.com/royroki/LetsGo/internal/common/logger"
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/common/token"
+	"github.com/royroki/LetsGo/internal/config/constants"
 	"github.com/royroki/LetsGo/internal/config/database"
 	config "github.com/royroki/LetsGo/internal/config/env"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/usecase"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/filter"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 	service "github.com/royroki/LetsGo/internal/modules/chat/domain/services"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
 	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/persistence"
 	web_socket_hub "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
 	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/worker"
 	"github.com/royroki/LetsGo/internal/modules/chat/presentation/controller"
+	"github.com/royroki/LetsGo/internal/modules/chat/presentation/middleware"
 	"github.com/royroki/LetsGo/internal/modules/chat/presentation/router"
 	web_socket "github.com/royroki/LetsGo/internal/modules/chat/presentation/websocket"
 )
 
 func main() {
-	config.NewEnvConfig()
+	envConfig := config.NewEnvConfig()
 	logger.NewZapLogger()
 
-	redisConfig := database.NewRedisConfig()
-	err := redisConfig.Ping()
-	if err != nil {
-		print("Failed To Ping Redis")
+	// r := router.SetupRouter(queue)
+
+	localHub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{
+		SendBuffer:   envConfig.WSSendBuffer,
+		Overflow:     web_socket_hub.OverflowPolicy(envConfig.WSOverflowPolicy),
+		BlockTimeout: envConfig.WSBlockTimeout,
+		PingInterval: envConfig.WSPingInterval,
+	})
+
+	// Readiness follows Redis, the matchmaking loop and shutdown
+	readiness := health.NewReadiness()
+
+	var (
+		redisClient *redis.Client
+		clusterHub  *web_socket_hub.ClusterHub
+		wsHub       repository.WebSocketRepository = localHub
+		userRepo    repository.UserRepository
+		chatRepo    repository.ChatRepository
+	)
+	serviceOptions := []service.Option{
+		service.WithSkipCooldown(envConfig.SkipCooldown),
+		service.WithNodeID(envConfig.NodeID),
+		service.WithFilters(newFilterChain(envConfig)),
+		service.WithProofOfWork(service.ProofOfWork{
+			Difficulty:      envConfig.PowDifficulty,
+			MaxDifficulty:   envConfig.PowMaxDifficulty,
+			FreeConnections: envConfig.PowFreeConnections,
+			FreeQueueLength: envConfig.PowFreeQueueLength,
+			Timeout:         envConfig.PowTimeout,
+		}),
 	}
 
-	redisClient := redisConfig.NewClient()
+	switch envConfig.StorageBackend {
+	case constants.StorageBackendMemory:
+		log.Println("🧪 Using in-memory storage: single instance only, session resume, rate limiting, transcripts, moderation and abuse scoring are off")
+		userRepo = memory.NewUserRepository()
+		chatRepo = memory.NewChatRepository()
+	default:
+		redisConfig := database.NewRedisConfig()
+		if err := redisConfig.Ping(); err != nil {
+			log.Printf("❌ Failed to ping Redis: %v", err)
+		}
+		redisClient = redisConfig.NewClient()
+		redisClient.AddHook(metrics.RedisHook{})
+		readiness.Register("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
 
-	// r := router.SetupRouter(queue)
+		clusterHub = web_socket_hub.NewClusterHub(localHub, redisClient, envConfig.NodeID)
+		wsHub = clusterHub
+		userRepo = persistence.NewUserRepository(redisClient, "waiting_queue")
+		chatRepo = persistence.NewChatRepository(redisClient)
+		sessionRepo := persistence.NewSessionRepository(redisClient)
+		rateLimitRepo := persistence.NewRateLimitRepository(redisClient)
+		banRepo := persistence.NewBanRepository(redisClient)
+
+		// Keep encrypted transcripts only when a key is configured; the key also seals report context
+		var sealer *encryption.Sealer
+		if envConfig.TranscriptKey != "" {
+			var err error
+			sealer, err = encryption.NewSealerFromBase64(envConfig.TranscriptKey)
+			if err != nil {
+				log.Fatalf("❌ Invalid %s: %v", constants.TranscriptKeyEnv, err)
+			}
+			transcriptRepo := persistence.NewTranscriptRepository(redisClient, sealer)
+			serviceOptions = append(serviceOptions, service.WithTranscripts(transcriptRepo, envConfig.TranscriptRetention))
+			log.Printf("📝 Recording encrypted transcripts, kept for %s", envConfig.TranscriptRetention)
+		}
+		reportRepo := persistence.NewReportRepository(redisClient, envConfig.ReportRetention, sealer)
+
+		serviceOptions = append(serviceOptions,
+			service.WithSessionResume(sessionRepo, envConfig.ResumeGrace),
+			service.WithRateLimits(rateLimitRepo, service.RateLimits{
+				ConnectionsPerMinute: envConfig.RateLimitConnectionsPerMinute,
+				MessagesPerSecond:    envConfig.RateLimitMessagesPerSecond,
+				BytesPerMinute:       envConfig.RateLimitBytesPerMinute,
+				MaxViolations:        envConfig.RateLimitMaxViolations,
+			}),
+			service.WithModeration(reportRepo, banRepo, envConfig.ReportContextMessages),
+			service.WithAbuseScoring(persistence.NewAbuseScoreRepository(redisClient), service.AbuseScoring{
+				HalfLife:       envConfig.AbuseHalfLife,
+				ShortChat:      envConfig.AbuseShortChat,
+				ThrottleAt:     float64(envConfig.AbuseThrottleScore),
+				ThrottleRate:   envConfig.AbuseThrottlePerMinute,
+				ShadowAt:       float64(envConfig.AbuseShadowScore),
+				ShadowDuration: envConfig.AbuseShadowDuration,
+				BanAt:          float64(envConfig.AbuseBanScore),
+				BanDuration:    envConfig.AbuseBanDuration,
+			}),
+		)
+	}
+	metrics.RegisterQueueLength(userRepo.GetQueueLength)
 
-	wsHub := web_socket_hub.NewWebSocketHub()
-	userRepo := persistence.NewUserRepository(redisClient, "waiting_queue")
-	chatRepo := persistence.NewChatRepository(redisClient)
+	// Record ended chats when a history database is configured
+	var postgresPool *pgxpool.Pool
+	if envConfig.PostgresURL != "" {
+		var err error
+		postgresPool, err = database.NewPostgresPool(context.Background(), envConfig.PostgresURL)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		historyRepo := persistence.NewChatHistoryRepository(postgresPool)
+		serviceOptions = append(serviceOptions, service.WithChatHistory(historyRepo))
+	}
 
-	chatService := service.NewChatService(chatRepo, userRepo, wsHub)
+	chatService := service.NewChatService(chatRepo, userRepo, wsHub, serviceOptions...)
 
 	// Use interface instead of concrete implementation
 	var chatUsecase interfaces.ChatUseCase = usecase.NewChatUseCase(chatService)
 
-	wsHandler := web_socket.NewWebSocketHandler(chatUsecase, wsHub)
+	resumeTokens := token.NewSigner([]byte(envConfig.ResumeTokenSecret))
+	wsHandler := web_socket.NewWebSocketHandler(chatUsecase, wsHub, web_socket.HandlerConfig{
+		ResumeTokens:     resumeTokens,
+		ResumeTokenTTL:   constants.ResumeTokenTTLHours * time.Hour,
+		AllowedOrigins:   envConfig.WSAllowedOrigins,
+		TrustedProxyHops: envConfig.TrustedProxyHops,
+		Readiness:        readiness,
+		MaxMessageBytes:  messageReadLimit(envConfig),
+		Connection: web_socket_hub.ConnectionConfig{
+			PongWait:  envConfig.WSPongWait,
+			WriteWait: envConfig.WSWriteWait,
+		},
+	})
 
 	chatController := controller.NewChatController(chatUsecase, wsHandler)
 
-	chatRouter := router.SetupChatRouter(chatController)
+	// Authenticate user routes when a signing key is configured
+	var jwtVerifier *token.JWTVerifier
+	if envConfig.AuthJWTKey != "" {
+		jwtVerifier = token.NewJWTVerifier([]byte(envConfig.AuthJWTKey), envConfig.AuthJWTIssuer, envConfig.AuthJWTAudience)
+	}
+	authMiddleware := middleware.NewAuthMiddleware(jwtVerifier)
 
 	// Start worker
-	chatWorker := worker.NewMatchmakingWorker(chatUsecase, userRepo)
+	chatWorker := worker.NewMatchmakingWorker(chatUsecase, userRepo, envConfig.MatchTagWait)
+
+	readiness.Register("matchmaking_worker", func(ctx context.Context) error {
+		return chatWorker.CheckAlive(envConfig.ReadyWorkerStall)
+	})
+	healthController := controller.NewHealthController(readiness)
+	adminController := controller.NewAdminController(chatUsecase)
+	adminMiddleware := middleware.NewAdminMiddleware(envConfig.AdminAPIToken)
+
+	chatRouter := router.SetupChatRouter(chatController, healthController, adminController, authMiddleware, adminMiddleware)
 
 	go chatWorker.Run()
 
+	// Start cross-instance message routing
+	if clusterHub != nil {
+		go clusterHub.Run()
+	}
+
 	server := &http.Server{
-		Addr:    ":8080",
+		Addr:    ":" + envConfig.ServerPort,
 		Handler: chatRouter,
 	}
 
 	// Start WebSocket Server
 	go func() {
-		log.Println("✅ WebSocket Server started at ws://localhost:8080/ws")
+		log.Printf("✅ WebSocket Server started at ws://localhost:%s/ws (node %s)", envConfig.ServerPort, envConfig.NodeID)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("❌ Server error: %v", err)
 		}
@@ -77,12 +210,23 @@ func main() {
 	<-stop
 	log.Println("🚀 Shutting down server...")
 
+	// Refuse new upgrades and fail /readyz so the load balancer moves traffic away
+	readiness.SetDraining()
+
+	// Stop background worker
+	chatWorker.Stop()
+
+	// End this instance's sessions; other instances keep their users and the shared queue
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), envConfig.DrainTimeout)
+	if err := chatUsecase.Drain(drainCtx); err != nil {
+		log.Printf("⚠️ Drain incomplete: %v", err)
+	}
+	cancelDrain()
+
 	// Cleanup resources
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Stop background worker
-	chatWorker.Stop() 
 	// Gracefully shutdown the HTTP server
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("❌ HTTP Server Shutdown Failed: %v", err)
@@ -91,16 +235,54 @@ func main() {
 	// Stop WebSocket connections
 	wsHub.Shutdown() // Implement a `Shutdown` method in `WebSocketHub` to clean connections.
 
-	if err = redisClient.Del(ctx, "waiting_queue").Err(); err != nil {
-		log.Println("Failed to clear waiting_queue")
+	// Close Redis connection
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			log.Fatalf("❌ Redis close error: %v", err)
+		}
 	}
-	log.Println("waiting_queue deleted")
 
-	// Close Redis connection
-	if err := redisClient.Close(); err != nil {
-		log.Fatalf("❌ Redis close error: %v", err)
+	// Close PostgreSQL connections once pending history writes have drained
+	if postgresPool != nil {
+		postgresPool.Close()
 	}
 
 	log.Println("✅ Server shutdown complete")
 
 }
+
+// messageEnvelopeBytes leaves room for the JSON envelope around a message's text
+const messageEnvelopeBytes = 1024
+
+// messageReadLimit bounds a single client message by the largest text the length filter
+// and the bytes-per-minute limit could accept; 0 leaves the handler default
+func messageReadLimit(envConfig *config.EnvConfig) int64 {
+	var limit int64
+	if runes := envConfig.FilterMaxMessageRunes; runes > 0 {
+		limit = int64(runes*6 + messageEnvelopeBytes) // A rune takes at most 6 bytes as a JSON \u escape
+	}
+	if budget := int64(envConfig.RateLimitBytesPerMinute); budget > 0 && (limit == 0 || budget+messageEnvelopeBytes < limit) {
+		limit = budget + messageEnvelopeBytes
+	}
+	return limit
+}
+
+// newFilterChain builds the content filters applied to chat messages, in order
+func newFilterChain(envConfig *config.EnvConfig) filter.Chain {
+	var blockLinks, blockPhoneNumbers filter.Filter
+	if envConfig.FilterBlockLinks {
+		blockLinks = filter.BlockLinks()
+	}
+	if envConfig.FilterBlockPhoneNumbers {
+		blockPhoneNumbers = filter.BlockPhoneNumbers()
+	}
+
+	return filter.NewChain(
+		filter.ValidUTF8(),
+		filter.MaxLength(envConfig.FilterMaxMessageRunes),
+		filter.WatchWords(envConfig.FilterWatchWords), // Before masking, so moderators see what was said
+		filter.Profanity(envConfig.FilterProfanityWords),
+		blockLinks,
+		blockPhoneNumbers,
+	)
+}
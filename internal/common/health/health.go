@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds a single readiness check
+const checkTimeout = 2 * time.Second
+
+// Check reports whether one dependency is ready to serve traffic
+type Check func(ctx context.Context) error
+
+// Readiness aggregates the named checks behind /readyz and tracks whether the
+// instance is draining for shutdown
+type Readiness struct {
+	mu       sync.RWMutex
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// Report is the outcome of a readiness probe
+type Report struct {
+	Ready    bool              `json:"ready"`
+	Draining bool              `json:"draining"`
+	Checks   map[string]string `json:"checks"` // "ok" or the failure
+}
+
+// NewReadiness creates a readiness probe with no checks
+func NewReadiness() *Readiness {
+	return &Readiness{checks: make(map[string]Check)}
+}
+
+// Register adds a named check; checks run in registration order
+func (r *Readiness) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.checks[name]; !exists {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// SetDraining marks the instance as shutting down so load balancers stop sending traffic
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether the instance is shutting down
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Check runs every registered check. The instance is ready when all checks pass and it is not draining.
+func (r *Readiness) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := Report{
+		Ready:    !r.Draining(),
+		Draining: r.Draining(),
+		Checks:   make(map[string]string, len(r.names)),
+	}
+	for _, name := range r.names {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := r.checks[name](checkCtx)
+		cancel()
+
+		if err != nil {
+			report.Ready = false
+			report.Checks[name] = err.Error()
+			continue
+		}
+		report.Checks[name] = "ok"
+	}
+	return report
+}
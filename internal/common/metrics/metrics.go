@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "letsgo"
+
+// Send failure reasons
+const (
+	SendFailureNotConnected = "not_connected" // The recipient has no connection on any node
+	SendFailureBufferFull   = "buffer_full"   // The recipient's send queue was full
+	SendFailureClosed       = "closed"        // The recipient's connection was closing
+	SendFailureEncode       = "encode"        // The message could not be encoded
+	SendFailureRoute        = "route"         // Looking up or publishing to the owning node failed
+)
+
+// Chat server metrics
+var (
+	ActiveConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_active_connections",
+		Help:      "WebSocket connections open on this instance.",
+	})
+
+	MatchesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "matches_total",
+		Help:      "Chats created by the matchmaking worker.",
+	})
+
+	MatchWaitSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "match_wait_seconds",
+		Help:      "How long matched users waited in the queue.",
+		Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 15, 30, 60, 120, 300},
+	})
+
+	MessagesForwarded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_forwarded_total",
+		Help:      "Chat messages forwarded to a partner.",
+	})
+
+	BytesRelayed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_relayed_total",
+		Help:      "Bytes of chat text forwarded to a partner.",
+	})
+
+	MessagesFiltered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_filtered_total",
+		Help:      "Chat messages blocked, rewritten or flagged by content filters, by filter and action.",
+	}, []string{"filter", "action"})
+
+	AbuseEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "abuse_events_total",
+		Help:      "Events counted towards user abuse scores, by event.",
+	}, []string{"event"})
+
+	Challenges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "challenges_total",
+		Help:      "Proof of work challenges, by outcome.",
+	}, []string{"outcome"})
+
+	SendFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "send_failures_total",
+		Help:      "Messages that could not be delivered to a client, by reason.",
+	}, []string{"reason"})
+
+	RedisCommandSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of Redis commands, by command.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1},
+	}, []string{"command"})
+)
+
+// queueLengthTimeout bounds the Redis lookup made on every scrape
+const queueLengthTimeout = 2 * time.Second
+
+// RegisterQueueLength exposes the waiting queue length, read from `length` at scrape time
+func RegisterQueueLength(length func(ctx context.Context) (int, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_length",
+		Help:      "Users waiting in the matchmaking queue.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), queueLengthTimeout)
+		defer cancel()
+
+		n, err := length(ctx)
+		if err != nil {
+			log.Printf("⚠️ Failed to read queue length for metrics: %v", err)
+			return 0
+		}
+		return float64(n)
+	})
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
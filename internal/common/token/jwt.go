@@ -0,0 +1,171 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrAlgorithm is returned for JWTs not signed with HS256
+var ErrAlgorithm = errors.New("unsupported token algorithm")
+
+// ErrNoExpiry is returned for JWTs without an "exp" claim, which would otherwise be valid forever
+var ErrNoExpiry = errors.New("token has no expiry")
+
+// Claims are the registered JWT claims the server understands
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// Audience is the "aud" claim, which RFC 7519 allows as a single string or an array of them
+type Audience []string
+
+// UnmarshalJSON accepts either form of the claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// MarshalJSON writes a single audience as a plain string
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains reports whether the token is intended for the audience
+func (a Audience) Contains(audience string) bool {
+	for _, entry := range a {
+		if entry == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// jwtHeader is the JOSE header of an HS256 token
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// JWTVerifier validates HS256-signed JSON Web Tokens
+type JWTVerifier struct {
+	key      []byte
+	issuer   string        // Required "iss" when set
+	audience string        // Required "aud" when set
+	leeway   time.Duration // Allowed clock skew for exp and nbf
+}
+
+// NewJWTVerifier creates a verifier for tokens signed with `key`.
+// Empty issuer or audience values are not checked.
+func NewJWTVerifier(key []byte, issuer, audience string) *JWTVerifier {
+	return &JWTVerifier{key: key, issuer: issuer, audience: audience, leeway: 30 * time.Second}
+}
+
+// Verify checks the signature and claims of a compact JWT and returns its claims
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformed
+	}
+	if header.Algorithm != "HS256" {
+		return nil, ErrAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := v.validate(&claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// Sign issues an HS256 JWT for the claims; used by tooling and tests that mint tokens
+func (v *JWTVerifier) Sign(claims Claims) (string, error) {
+	header, err := json.Marshal(jwtHeader{Algorithm: "HS256", Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// validate checks the time, issuer, audience and subject claims; "exp" is required
+func (v *JWTVerifier) validate(claims *Claims) error {
+	now := time.Now()
+	if claims.Subject == "" {
+		return ErrMalformed
+	}
+	if claims.ExpiresAt == 0 {
+		return ErrNoExpiry
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return errors.New("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return errors.New("unexpected token issuer")
+	}
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return errors.New("unexpected token audience")
+	}
+	return nil
+}
+
+// decodeSegment decodes one base64url JSON segment of a JWT
+func decodeSegment(segment string, into any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJWTVerifierRequiresExpiry(t *testing.T) {
+	verifier := NewJWTVerifier([]byte("test-key"), "", "")
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   error
+	}{
+		{"valid", Claims{Subject: "alice", ExpiresAt: now.Add(time.Hour).Unix()}, nil},
+		{"no expiry", Claims{Subject: "alice"}, ErrNoExpiry},
+		{"expired", Claims{Subject: "alice", ExpiresAt: now.Add(-time.Hour).Unix()}, ErrExpired},
+		{"no subject", Claims{ExpiresAt: now.Add(time.Hour).Unix()}, ErrMalformed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := verifier.Sign(test.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := verifier.Verify(raw); !errors.Is(err, test.want) {
+				t.Errorf("Verify = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierAcceptsAudienceStringOrArray(t *testing.T) {
+	verifier := NewJWTVerifier([]byte("test-key"), "", "letsgo")
+	expires := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		aud     string
+		wantErr bool
+	}{
+		{"string", `"letsgo"`, false},
+		{"array", `["billing","letsgo"]`, false},
+		{"other string", `"billing"`, true},
+		{"other array", `["billing"]`, true},
+		{"missing", `null`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := fmt.Sprintf(`{"sub":"alice","exp":%d,"aud":%s}`, expires, test.aud)
+			if _, err := verifier.Verify(signPayload(verifier.key, payload)); (err != nil) != test.wantErr {
+				t.Errorf("Verify = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// signPayload signs a raw claims payload, so tests can send JSON that Claims would not produce
+func signPayload(key []byte, payload string) string {
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -4,10 +4,11 @@ package config
 
 // Config defines the contract for configuration-related methods.
 type Config interface {
-	LoadEnv() error          // Loads environment variables (e.g., from .env file)
-	Get(key string) string   // Retrieves a string value for the given key
-	GetInt(key string) int   // Retrieves an integer value for the given key
-	GetBool(key string) bool // Retrieves a boolean value for the given key
+	LoadEnv() error                          // Loads environment variables (e.g., from .env file)
+	Get(key string) string                   // Retrieves a string value for the given key
+	GetInt(key string) int                   // Retrieves an integer value for the given key
+	GetBool(key string) bool                 // Retrieves a boolean value for the given key
+	GetIntOrDefault(key string, def int) int // Retrieves an integer value, or def when unset
 }
 
 // DBConfig defines the contract for database configuration.
@@ -0,0 +1,13 @@
+package constants
+
+// Abuse scoring environment variables
+const (
+	AbuseHalfLifeMinutesEnv   = "ABUSE_HALF_LIFE_MINUTES"   // How long an abuse score takes to halve; 0 disables scoring
+	AbuseShortChatSecondsEnv  = "ABUSE_SHORT_CHAT_SECONDS"  // Chats skipped sooner count against the skipped user
+	AbuseThrottleScoreEnv     = "ABUSE_THROTTLE_SCORE"      // Score from which messages are throttled; 0 never throttles
+	AbuseThrottlePerMinuteEnv = "ABUSE_THROTTLE_PER_MINUTE" // Messages per minute allowed while throttled
+	AbuseShadowScoreEnv       = "ABUSE_SHADOW_SCORE"        // Score from which users are shadow-banned; 0 never shadow-bans
+	AbuseShadowHoursEnv       = "ABUSE_SHADOW_HOURS"        // How long an automatic shadow ban lasts
+	AbuseBanScoreEnv          = "ABUSE_BAN_SCORE"           // Score from which users are banned; 0 never bans
+	AbuseBanMinutesEnv        = "ABUSE_BAN_MINUTES"         // How long an automatic ban lasts
+)
@@ -0,0 +1,13 @@
+package constants
+
+// Abuse scoring environment values
+const (
+	AbuseDefHalfLifeMinutes   = 30
+	AbuseDefShortChatSeconds  = 5
+	AbuseDefThrottleScore     = 20
+	AbuseDefThrottlePerMinute = 10
+	AbuseDefShadowScore       = 50
+	AbuseDefShadowHours       = 24
+	AbuseDefBanScore          = 100
+	AbuseDefBanMinutes        = 60
+)
@@ -0,0 +1,6 @@
+package constants
+
+// Admin environment variables
+const (
+	AdminAPITokenEnv = "ADMIN_API_TOKEN" // Bearer token for /admin; empty disables the admin API
+)
@@ -0,0 +1,9 @@
+package constants
+
+// Auth environment variables
+const (
+	AuthJWTKeyEnv       = "AUTH_JWT_KEY" // Empty disables authentication
+	AuthJWTIssuerEnv    = "AUTH_JWT_ISSUER"
+	AuthJWTAudienceEnv  = "AUTH_JWT_AUDIENCE"
+	WSAllowedOriginsEnv = "WS_ALLOWED_ORIGINS" // Comma-separated; empty or "*" allows any
+)
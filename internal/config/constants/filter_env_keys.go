@@ -0,0 +1,10 @@
+package constants
+
+// Content filter environment variables
+const (
+	FilterMaxMessageRunesEnv   = "FILTER_MAX_MESSAGE_RUNES"   // Longest chat message in characters; 0 allows any length
+	FilterProfanityWordsEnv    = "FILTER_PROFANITY_WORDS"     // Comma-separated words masked with asterisks
+	FilterWatchWordsEnv        = "FILTER_WATCH_WORDS"         // Comma-separated words that flag a message for moderators
+	FilterBlockLinksEnv        = "FILTER_BLOCK_LINKS"         // Block messages containing URLs; "false" allows them
+	FilterBlockPhoneNumbersEnv = "FILTER_BLOCK_PHONE_NUMBERS" // Block messages containing phone numbers; "false" allows them
+)
@@ -0,0 +1,6 @@
+package constants
+
+// Content filter environment values
+const (
+	FilterDefMaxMessageRunes = 2000
+)
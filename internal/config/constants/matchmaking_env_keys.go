@@ -0,0 +1,7 @@
+package constants
+
+// Matchmaking environment variables
+const (
+	MatchTagWaitSecondsEnv      = "MATCH_TAG_WAIT_SECONDS"
+	MatchSkipCooldownSecondsEnv = "MATCH_SKIP_COOLDOWN_SECONDS"
+)
@@ -0,0 +1,9 @@
+package constants
+
+// Matchmaking environment values
+const (
+	MatchDefTagWaitSeconds      = 15
+	MatchDefSkipCooldownSeconds = 60
+	MatchMaxTags                = 10
+	MatchMaxTagLength           = 32
+)
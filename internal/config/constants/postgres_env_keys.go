@@ -0,0 +1,6 @@
+package constants
+
+// PostgreSQL environment variables
+const (
+	PostgresURLEnv = "POSTGRES_URL" // Connection string for chat history; empty disables history
+)
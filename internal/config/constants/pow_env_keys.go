@@ -0,0 +1,10 @@
+package constants
+
+// Proof of work environment variables
+const (
+	PowDifficultyEnv            = "POW_DIFFICULTY"                  // Leading zero bits every new client must find; 0 disables challenges
+	PowMaxDifficultyEnv         = "POW_MAX_DIFFICULTY"              // Ceiling for the raised difficulty
+	PowFreeConnectionsPerMinEnv = "POW_FREE_CONNECTIONS_PER_MINUTE" // Connections per client IP before difficulty rises
+	PowFreeQueueLengthEnv       = "POW_FREE_QUEUE_LENGTH"           // Waiting users before difficulty rises
+	PowTimeoutSecondsEnv        = "POW_TIMEOUT_SECONDS"             // How long a client has to solve its challenge
+)
@@ -0,0 +1,10 @@
+package constants
+
+// Proof of work environment values
+const (
+	PowDefDifficulty            = 0
+	PowDefMaxDifficulty         = 24
+	PowDefFreeConnectionsPerMin = 5
+	PowDefFreeQueueLength       = 200
+	PowDefTimeoutSeconds        = 60
+)
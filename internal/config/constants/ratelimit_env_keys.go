@@ -0,0 +1,11 @@
+package constants
+
+// Rate limit environment variables
+const (
+	RateLimitConnectionsPerMinuteEnv = "RATE_LIMIT_CONNECTIONS_PER_MINUTE" // Per client IP
+	RateLimitMessagesPerSecondEnv    = "RATE_LIMIT_MESSAGES_PER_SECOND"    // Per user
+	RateLimitBytesPerMinuteEnv       = "RATE_LIMIT_BYTES_PER_MINUTE"       // Per user
+	RateLimitMaxViolationsEnv        = "RATE_LIMIT_MAX_VIOLATIONS"         // Per user per minute before disconnecting
+	TrustProxyHeadersEnv             = "TRUST_PROXY_HEADERS"               // Read the client IP from X-Forwarded-For
+	TrustedProxyHopsEnv              = "TRUSTED_PROXY_HOPS"                // Proxies in front of the server that append to X-Forwarded-For
+)
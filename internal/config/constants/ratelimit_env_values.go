@@ -0,0 +1,10 @@
+package constants
+
+// Rate limit environment values; 0 disables a limit
+const (
+	RateLimitDefConnectionsPerMinute = 30
+	RateLimitDefMessagesPerSecond    = 5
+	RateLimitDefBytesPerMinute       = 16384
+	RateLimitDefMaxViolations        = 5
+	DefTrustedProxyHops              = 1
+)
@@ -0,0 +1,7 @@
+package constants
+
+// Report environment variables
+const (
+	ReportContextMessagesEnv = "REPORT_CONTEXT_MESSAGES" // Recent messages attached to a report; 0 attaches none. Plaintext without TRANSCRIPT_KEY
+	ReportRetentionDaysEnv   = "REPORT_RETENTION_DAYS"   // How long reports are kept after they are filed
+)
@@ -0,0 +1,8 @@
+package constants
+
+// Report environment values
+const (
+	ReportDefContextMessages         = 20 // With TRANSCRIPT_KEY set, which seals the messages
+	ReportDefContextMessagesUnsealed = 0  // Without a key the messages would be stored in plaintext
+	ReportDefRetentionDays           = 30
+)
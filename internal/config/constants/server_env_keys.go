@@ -0,0 +1,10 @@
+package constants
+
+// Server environment variables
+const (
+	ServerPortEnv = "SERVER_PORT"
+	NodeIDEnv     = "NODE_ID"
+
+	ReadyWorkerStallSecondsEnv = "READY_WORKER_STALL_SECONDS" // /readyz fails once the worker loop stalls this long
+	DrainTimeoutSecondsEnv     = "DRAIN_TIMEOUT_SECONDS"      // How long shutdown waits for local sessions to end
+)
@@ -0,0 +1,9 @@
+package constants
+
+// Server environment values
+const (
+	ServerDefPortStr = "8080"
+
+	ReadyDefWorkerStallSeconds = 30
+	DrainDefTimeoutSeconds     = 10
+)
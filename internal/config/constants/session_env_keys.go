@@ -0,0 +1,7 @@
+package constants
+
+// Session environment variables
+const (
+	ResumeGraceSecondsEnv = "RESUME_GRACE_SECONDS"
+	ResumeTokenSecretEnv  = "RESUME_TOKEN_SECRET"
+)
@@ -0,0 +1,7 @@
+package constants
+
+// Session environment values
+const (
+	ResumeDefGraceSeconds = 30 // 0 disables session resume
+	ResumeTokenTTLHours   = 24
+)
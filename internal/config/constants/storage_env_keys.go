@@ -0,0 +1,6 @@
+package constants
+
+// Storage environment variables
+const (
+	StorageBackendEnv = "STORAGE_BACKEND"
+)
@@ -0,0 +1,9 @@
+package constants
+
+// Storage environment values
+const (
+	StorageBackendRedis  = "redis"  // Shared state in Redis; required for more than one instance
+	StorageBackendMemory = "memory" // Process memory; single instance, no Redis needed
+
+	StorageDefBackend = StorageBackendRedis
+)
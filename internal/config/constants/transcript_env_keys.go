@@ -0,0 +1,7 @@
+package constants
+
+// Transcript environment variables
+const (
+	TranscriptKeyEnv            = "TRANSCRIPT_KEY"             // Base64 AES-256 key; empty disables transcripts
+	TranscriptRetentionHoursEnv = "TRANSCRIPT_RETENTION_HOURS" // How long a transcript is kept after its last message
+)
@@ -0,0 +1,6 @@
+package constants
+
+// Transcript environment values
+const (
+	TranscriptDefRetentionHours = 72
+)
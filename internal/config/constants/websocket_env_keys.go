@@ -0,0 +1,11 @@
+package constants
+
+// WebSocket environment variables
+const (
+	WSSendBufferEnv     = "WS_SEND_BUFFER"
+	WSOverflowPolicyEnv = "WS_OVERFLOW_POLICY"
+	WSBlockTimeoutMsEnv = "WS_BLOCK_TIMEOUT_MS"
+	WSPingIntervalEnv   = "WS_PING_INTERVAL_SECONDS"
+	WSPongWaitEnv       = "WS_PONG_WAIT_SECONDS"
+	WSWriteWaitEnv      = "WS_WRITE_WAIT_SECONDS"
+)
@@ -0,0 +1,11 @@
+package constants
+
+// WebSocket environment values
+const (
+	WSDefSendBuffer     = 64
+	WSDefOverflowPolicy = "drop" // drop | disconnect | block
+	WSDefBlockTimeoutMs = 1000
+	WSDefPingInterval   = 30
+	WSDefPongWait       = 60
+	WSDefWriteWait      = 10
+)
@@ -0,0 +1,17 @@
+-- Metadata about ended chats; message bodies are never stored here
+CREATE TABLE IF NOT EXISTS chat_history (
+    chat_id     TEXT PRIMARY KEY,
+    user_a      TEXT        NOT NULL,
+    user_b      TEXT        NOT NULL,
+    start_time  TIMESTAMPTZ NOT NULL,
+    end_time    TIMESTAMPTZ NOT NULL,
+    end_reason  TEXT        NOT NULL,
+    ended_by    TEXT        NOT NULL,
+    messages_a  BIGINT      NOT NULL DEFAULT 0,
+    messages_b  BIGINT      NOT NULL DEFAULT 0,
+    node_id     TEXT        NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS chat_history_user_a_idx ON chat_history (user_a, start_time DESC);
+CREATE INDEX IF NOT EXISTS chat_history_user_b_idx ON chat_history (user_b, start_time DESC);
+CREATE INDEX IF NOT EXISTS chat_history_end_time_idx ON chat_history (end_time);
@@ -6,7 +6,10 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/royroki/LetsGo/internal/config"
 	"github.com/royroki/LetsGo/internal/config/constants"
@@ -14,15 +17,76 @@ import (
 
 // EnvConfig holds methods for interacting with environment variables.
 type EnvConfig struct {
-	RedisAddress  string
-	RedisPassword string
-	RedisDB       int
-	RedisPort     int
-	LoggerType    string
+	RedisAddress   string
+	RedisPassword  string
+	RedisDB        int
+	RedisPort      int
+	PostgresURL    string
+	LoggerType     string
+	StorageBackend string
+	MatchTagWait   time.Duration
+	SkipCooldown   time.Duration
+	ServerPort     string
+	NodeID         string
+
+	ReadyWorkerStall time.Duration
+	DrainTimeout     time.Duration
+
+	WSSendBuffer     int
+	WSOverflowPolicy string
+	WSBlockTimeout   time.Duration
+	WSPingInterval   time.Duration
+	WSPongWait       time.Duration
+	WSWriteWait      time.Duration
+
+	ResumeGrace       time.Duration
+	ResumeTokenSecret string
+
+	AuthJWTKey       string
+	AuthJWTIssuer    string
+	AuthJWTAudience  string
+	WSAllowedOrigins []string
+
+	RateLimitConnectionsPerMinute int
+	RateLimitMessagesPerSecond    int
+	RateLimitBytesPerMinute       int
+	RateLimitMaxViolations        int
+	TrustedProxyHops              int // 0 ignores X-Forwarded-For
+
+	AdminAPIToken       string
+	TranscriptKey       string
+	TranscriptRetention time.Duration
+
+	ReportContextMessages int
+	ReportRetention       time.Duration
+
+	FilterMaxMessageRunes   int
+	FilterProfanityWords    []string
+	FilterWatchWords        []string
+	FilterBlockLinks        bool
+	FilterBlockPhoneNumbers bool
+
+	AbuseHalfLife          time.Duration
+	AbuseShortChat         time.Duration
+	AbuseThrottleScore     int
+	AbuseThrottlePerMinute int
+	AbuseShadowScore       int
+	AbuseShadowDuration    time.Duration
+	AbuseBanScore          int
+	AbuseBanDuration       time.Duration
+
+	PowDifficulty      int
+	PowMaxDifficulty   int
+	PowFreeConnections int
+	PowFreeQueueLength int
+	PowTimeout         time.Duration
 }
 
+// Ensure EnvConfig implements Config
+var _ config.Config = &EnvConfig{}
+
 // NewEnvConfig creates a new EnvConfig instance and loads environment variables.
-func NewEnvConfig() config.Config {
+func NewEnvConfig() *EnvConfig {
 	config := &EnvConfig{}
 	if err := config.LoadEnv(); err != nil {
 		log.Fatalf("Error loading environment variables: %v", err)
@@ -54,18 +118,155 @@ func (c *EnvConfig) LoadEnv() error {
 		return err
 	}
 
-	// Load Redis configurations
-	c.RedisAddress = os.Getenv(constants.RedisAddressEnv)
-	c.RedisPassword = os.Getenv(constants.RedisPasswordEnv)
-	if c.RedisPassword == "" {
-		log.Println("No Redis password set, connecting without password")
+	// Load Storage backend
+	c.StorageBackend = os.Getenv(constants.StorageBackendEnv)
+	switch c.StorageBackend {
+	case constants.StorageBackendRedis, constants.StorageBackendMemory:
+	case "":
+		c.StorageBackend = constants.StorageDefBackend
+	default:
+		log.Fatalf("Invalid %s %q (want %q or %q)", constants.StorageBackendEnv, c.StorageBackend, constants.StorageBackendRedis, constants.StorageBackendMemory)
+	}
+
+	// Load Redis configurations; the in-memory backend runs without Redis
+	if c.StorageBackend == constants.StorageBackendRedis {
+		c.RedisAddress = os.Getenv(constants.RedisAddressEnv)
+		c.RedisPassword = os.Getenv(constants.RedisPasswordEnv)
+		if c.RedisPassword == "" {
+			log.Println("No Redis password set, connecting without password")
+		}
+		c.RedisDB = c.GetInt(constants.RedisDBEnv)
+		c.RedisPort = c.GetInt(constants.RedisPortEnv)
+	}
+
+	// Load PostgreSQL configuration; chat history is optional
+	c.PostgresURL = os.Getenv(constants.PostgresURLEnv)
+	if c.PostgresURL == "" {
+		log.Println("POSTGRES_URL is not set, chat history is not recorded")
 	}
-	c.RedisDB = c.GetInt(constants.RedisDBEnv)
-	c.RedisPort = c.GetInt(constants.RedisPortEnv)
 
 	// Load Logger Type
 	c.LoggerType = c.Get(constants.LoggerTypeEnv)
 
+	// Load Server configurations
+	c.ServerPort = os.Getenv(constants.ServerPortEnv)
+	if c.ServerPort == "" {
+		c.ServerPort = constants.ServerDefPortStr
+	}
+	c.NodeID = os.Getenv(constants.NodeIDEnv)
+	if c.NodeID == "" {
+		c.NodeID = uuid.New().String() // Unique per process, so several instances can share a host
+	}
+
+	c.ReadyWorkerStall = time.Duration(c.GetIntOrDefault(constants.ReadyWorkerStallSecondsEnv, constants.ReadyDefWorkerStallSeconds)) * time.Second
+	c.DrainTimeout = time.Duration(c.GetIntOrDefault(constants.DrainTimeoutSecondsEnv, constants.DrainDefTimeoutSeconds)) * time.Second
+
+	// Load WebSocket configurations
+	c.WSSendBuffer = c.GetIntOrDefault(constants.WSSendBufferEnv, constants.WSDefSendBuffer)
+	c.WSOverflowPolicy = os.Getenv(constants.WSOverflowPolicyEnv)
+	if c.WSOverflowPolicy == "" {
+		c.WSOverflowPolicy = constants.WSDefOverflowPolicy
+	}
+	c.WSBlockTimeout = time.Duration(c.GetIntOrDefault(constants.WSBlockTimeoutMsEnv, constants.WSDefBlockTimeoutMs)) * time.Millisecond
+	c.WSPingInterval = time.Duration(c.GetIntOrDefault(constants.WSPingIntervalEnv, constants.WSDefPingInterval)) * time.Second
+	c.WSPongWait = time.Duration(c.GetIntOrDefault(constants.WSPongWaitEnv, constants.WSDefPongWait)) * time.Second
+	c.WSWriteWait = time.Duration(c.GetIntOrDefault(constants.WSWriteWaitEnv, constants.WSDefWriteWait)) * time.Second
+	if c.WSPongWait <= c.WSPingInterval {
+		log.Printf("%s must exceed %s, using %s", constants.WSPongWaitEnv, constants.WSPingIntervalEnv, 2*c.WSPingInterval)
+		c.WSPongWait = 2 * c.WSPingInterval // A pong must have time to arrive before the read deadline
+	}
+
+	// Load Session configurations
+	c.ResumeGrace = time.Duration(c.GetIntOrDefault(constants.ResumeGraceSecondsEnv, constants.ResumeDefGraceSeconds)) * time.Second
+	c.ResumeTokenSecret = os.Getenv(constants.ResumeTokenSecretEnv)
+	if c.ResumeTokenSecret == "" {
+		log.Println("RESUME_TOKEN_SECRET is not set, using a random secret (sessions cannot resume on other instances)")
+		c.ResumeTokenSecret = uuid.New().String()
+	}
+
+	// Load Auth configurations
+	c.AuthJWTKey = os.Getenv(constants.AuthJWTKeyEnv)
+	if c.AuthJWTKey == "" {
+		log.Println("AUTH_JWT_KEY is not set, /ws accepts anonymous connections")
+	}
+	c.AuthJWTIssuer = os.Getenv(constants.AuthJWTIssuerEnv)
+	c.AuthJWTAudience = os.Getenv(constants.AuthJWTAudienceEnv)
+	c.WSAllowedOrigins = splitList(os.Getenv(constants.WSAllowedOriginsEnv))
+	if len(c.WSAllowedOrigins) == 0 {
+		log.Println("WS_ALLOWED_ORIGINS is not set, WebSocket upgrades are accepted from any origin")
+	}
+
+	// Load Rate limit configurations
+	c.RateLimitConnectionsPerMinute = c.GetIntOrDefault(constants.RateLimitConnectionsPerMinuteEnv, constants.RateLimitDefConnectionsPerMinute)
+	c.RateLimitMessagesPerSecond = c.GetIntOrDefault(constants.RateLimitMessagesPerSecondEnv, constants.RateLimitDefMessagesPerSecond)
+	c.RateLimitBytesPerMinute = c.GetIntOrDefault(constants.RateLimitBytesPerMinuteEnv, constants.RateLimitDefBytesPerMinute)
+	c.RateLimitMaxViolations = c.GetIntOrDefault(constants.RateLimitMaxViolationsEnv, constants.RateLimitDefMaxViolations)
+	if os.Getenv(constants.TrustProxyHeadersEnv) == "true" {
+		c.TrustedProxyHops = c.GetIntOrDefault(constants.TrustedProxyHopsEnv, constants.DefTrustedProxyHops)
+		if c.TrustedProxyHops < 1 {
+			log.Printf("%s must be at least 1, using %d", constants.TrustedProxyHopsEnv, constants.DefTrustedProxyHops)
+			c.TrustedProxyHops = constants.DefTrustedProxyHops
+		}
+	}
+
+	// Load Moderation configurations
+	c.AdminAPIToken = os.Getenv(constants.AdminAPITokenEnv)
+	if c.AdminAPIToken == "" {
+		log.Println("ADMIN_API_TOKEN is not set, the admin API is disabled")
+	}
+	c.TranscriptKey = os.Getenv(constants.TranscriptKeyEnv)
+	c.TranscriptRetention = time.Duration(c.GetIntOrDefault(constants.TranscriptRetentionHoursEnv, constants.TranscriptDefRetentionHours)) * time.Hour
+	// Report context is sealed with the transcript key, so without one none is kept unless asked for
+	if c.TranscriptKey != "" {
+		c.ReportContextMessages = c.GetIntOrDefault(constants.ReportContextMessagesEnv, constants.ReportDefContextMessages)
+	} else {
+		c.ReportContextMessages = c.GetIntOrDefault(constants.ReportContextMessagesEnv, constants.ReportDefContextMessagesUnsealed)
+		if c.ReportContextMessages > 0 {
+			log.Println("TRANSCRIPT_KEY is not set, messages attached to reports are stored in plaintext")
+		}
+	}
+	c.ReportRetention = time.Duration(c.GetIntOrDefault(constants.ReportRetentionDaysEnv, constants.ReportDefRetentionDays)) * 24 * time.Hour
+
+	// Load Content filter configurations
+	c.FilterMaxMessageRunes = c.GetIntOrDefault(constants.FilterMaxMessageRunesEnv, constants.FilterDefMaxMessageRunes)
+	c.FilterProfanityWords = splitList(os.Getenv(constants.FilterProfanityWordsEnv))
+	c.FilterWatchWords = splitList(os.Getenv(constants.FilterWatchWordsEnv))
+	c.FilterBlockLinks = os.Getenv(constants.FilterBlockLinksEnv) != "false"
+	c.FilterBlockPhoneNumbers = os.Getenv(constants.FilterBlockPhoneNumbersEnv) != "false"
+
+	// Load Abuse scoring configurations
+	c.AbuseHalfLife = time.Duration(c.GetIntOrDefault(constants.AbuseHalfLifeMinutesEnv, constants.AbuseDefHalfLifeMinutes)) * time.Minute
+	c.AbuseShortChat = time.Duration(c.GetIntOrDefault(constants.AbuseShortChatSecondsEnv, constants.AbuseDefShortChatSeconds)) * time.Second
+	c.AbuseThrottleScore = c.GetIntOrDefault(constants.AbuseThrottleScoreEnv, constants.AbuseDefThrottleScore)
+	c.AbuseThrottlePerMinute = c.GetIntOrDefault(constants.AbuseThrottlePerMinuteEnv, constants.AbuseDefThrottlePerMinute)
+	if c.AbuseThrottlePerMinute <= 0 {
+		log.Printf("%s must be positive, using %d", constants.AbuseThrottlePerMinuteEnv, constants.AbuseDefThrottlePerMinute)
+		c.AbuseThrottlePerMinute = constants.AbuseDefThrottlePerMinute
+	}
+	c.AbuseShadowScore = c.GetIntOrDefault(constants.AbuseShadowScoreEnv, constants.AbuseDefShadowScore)
+	c.AbuseShadowDuration = time.Duration(c.GetIntOrDefault(constants.AbuseShadowHoursEnv, constants.AbuseDefShadowHours)) * time.Hour
+	c.AbuseBanScore = c.GetIntOrDefault(constants.AbuseBanScoreEnv, constants.AbuseDefBanScore)
+	c.AbuseBanDuration = time.Duration(c.GetIntOrDefault(constants.AbuseBanMinutesEnv, constants.AbuseDefBanMinutes)) * time.Minute
+
+	// Load Proof of work configurations
+	c.PowDifficulty = c.GetIntOrDefault(constants.PowDifficultyEnv, constants.PowDefDifficulty)
+	c.PowMaxDifficulty = c.GetIntOrDefault(constants.PowMaxDifficultyEnv, constants.PowDefMaxDifficulty)
+	if c.PowMaxDifficulty < c.PowDifficulty {
+		log.Printf("%s is below %s, using %d", constants.PowMaxDifficultyEnv, constants.PowDifficultyEnv, c.PowDifficulty)
+		c.PowMaxDifficulty = c.PowDifficulty
+	}
+	c.PowFreeConnections = c.GetIntOrDefault(constants.PowFreeConnectionsPerMinEnv, constants.PowDefFreeConnectionsPerMin)
+	c.PowFreeQueueLength = c.GetIntOrDefault(constants.PowFreeQueueLengthEnv, constants.PowDefFreeQueueLength)
+	c.PowTimeout = time.Duration(c.GetIntOrDefault(constants.PowTimeoutSecondsEnv, constants.PowDefTimeoutSeconds)) * time.Second
+	if c.PowTimeout <= 0 {
+		log.Printf("%s must be positive, using %d", constants.PowTimeoutSecondsEnv, constants.PowDefTimeoutSeconds)
+		c.PowTimeout = constants.PowDefTimeoutSeconds * time.Second
+	}
+
+	// Load Matchmaking configurations
+	c.MatchTagWait = time.Duration(c.GetIntOrDefault(constants.MatchTagWaitSecondsEnv, constants.MatchDefTagWaitSeconds)) * time.Second
+	c.SkipCooldown = time.Duration(c.GetIntOrDefault(constants.MatchSkipCooldownSecondsEnv, constants.MatchDefSkipCooldownSeconds)) * time.Second
+
 	return nil
 }
 
@@ -92,3 +293,22 @@ func (e *EnvConfig) GetInt(key string) int {
 	}
 	return intValue
 }
+
+// GetIntOrDefault retrieves an integer value, falling back to def when the variable is not set.
+func (e *EnvConfig) GetIntOrDefault(key string, def int) int {
+	if os.Getenv(key) == "" {
+		return def
+	}
+	return e.GetInt(key)
+}
+
+// splitList splits a comma-separated value, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
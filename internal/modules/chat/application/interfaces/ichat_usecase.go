@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 )
@@ -10,7 +11,23 @@ import (
 type ChatUseCase interface {
 	GetChatPartner(ctx context.Context, userID string) (any, error)
 	EndChatSession(ctx context.Context, userID string) error
-	HandleNewConnection(ctx context.Context, userID string) error
+	SkipPartner(ctx context.Context, userID string) error
+	HandleNewConnection(ctx context.Context, userID string, client entity.ClientInfo, tags []string) error
+	ResumeSession(ctx context.Context, userID string) error
+	HandleResumedConnection(ctx context.Context, userID string) error
+	IsUserActive(ctx context.Context, userID string) bool
+	AllowConnection(ctx context.Context, clientIP string) bool
+	Drain(ctx context.Context) error
+	GetTranscript(ctx context.Context, chatID string) ([]entity.TranscriptEntry, error)
+	FindBan(ctx context.Context, userID string, client entity.ClientInfo) *entity.Ban
+	ScreenQueuedUser(ctx context.Context, user entity.User) (bool, error)
+	CreateBan(ctx context.Context, kind entity.BanKind, mode entity.BanMode, value, reason, issuedBy string, duration time.Duration) (*entity.Ban, error)
+	ListBans(ctx context.Context, limit int) ([]entity.Ban, error)
+	LiftBan(ctx context.Context, banID, liftedBy string) error
+	ListReports(ctx context.Context, status entity.ReportStatus, limit int) ([]entity.Report, error)
+	GetReport(ctx context.Context, reportID string) (*entity.Report, error)
+	ResolveReport(ctx context.Context, reportID string, action entity.ReportAction, note, resolvedBy string, banDuration time.Duration) (*entity.Report, error)
 	HandleChatPair(ctx context.Context, userA, userB entity.User) error
+	RecoverStaleClaims(ctx context.Context) error
 	ListenFromConnection(userID string)
 }
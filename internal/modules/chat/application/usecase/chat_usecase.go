@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/royroki/LetsGo/internal/config/constants"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 	service "github.com/royroki/LetsGo/internal/modules/chat/domain/services"
@@ -27,23 +28,30 @@ func (c *ChatUseCase) GetChatPartner(ctx context.Context, userID string) (any, e
 }
 
 func (c *ChatUseCase) EndChatSession(ctx context.Context, userID string) error {
-	return c.chatService.EndChatSession(ctx, userID)
+	return c.chatService.EndChatSession(ctx, userID, entity.EndReasonDisconnected)
+}
+
+// SkipPartner ends the user's current chat and re-queues both users without disconnecting
+func (c *ChatUseCase) SkipPartner(ctx context.Context, userID string) error {
+	return c.chatService.SkipPartner(ctx, userID)
 }
 
 // HandleWSConnection manages WebSocket connections, pairing users, and messaging.
-func (c *ChatUseCase) HandleNewConnection(ctx context.Context, userId string) error {
+func (c *ChatUseCase) HandleNewConnection(ctx context.Context, userId string, client entity.ClientInfo, tags []string) error {
 	log.Printf("User connected: (ID: %s)", userId)
 
 	// Create a User entity
 	user := entity.User{
 		UserID:   userId,
-		ChatID: "",
+		ChatID:   "",
 		JoinTime: time.Now(),
 		Chatted:  0,
+		Tags:     entity.NormalizeTags(tags, constants.MatchMaxTags, constants.MatchMaxTagLength),
+		Client:   client,
 	}
 
-	// Add user to queue (Worker will pair them)
-	err := c.chatService.AddUserToQueue(ctx, user)
+	// Add user to queue (Worker will pair them), after a proof of work when one is required
+	err := c.chatService.JoinQueue(ctx, user)
 	if err != nil {
 		log.Printf("Error adding user to queue: %v", err)
 		return err
@@ -52,8 +60,82 @@ func (c *ChatUseCase) HandleNewConnection(ctx context.Context, userId string) er
 	return nil
 }
 
-// HandleChatPair creates a chat session when two users are matched
+// ResumeSession claims a suspended session for a reconnecting user
+func (c *ChatUseCase) ResumeSession(ctx context.Context, userID string) error {
+	return c.chatService.ResumeSession(ctx, userID)
+}
+
+// HandleResumedConnection reattaches a reconnected user to their chat or the queue
+func (c *ChatUseCase) HandleResumedConnection(ctx context.Context, userID string) error {
+	log.Printf("User reconnected: (ID: %s)", userID)
+	return c.chatService.HandleResumedConnection(ctx, userID)
+}
+
+// IsUserActive reports whether the user still has a session, connected or suspended
+func (c *ChatUseCase) IsUserActive(ctx context.Context, userID string) bool {
+	return c.chatService.IsUserActive(ctx, userID)
+}
+
+// AllowConnection reports whether the client IP is within its connection rate limit
+func (c *ChatUseCase) AllowConnection(ctx context.Context, clientIP string) bool {
+	return c.chatService.AllowConnection(ctx, clientIP)
+}
+
+// Drain ends this instance's sessions ahead of shutdown
+func (c *ChatUseCase) Drain(ctx context.Context) error {
+	return c.chatService.Drain(ctx)
+}
+
+// GetTranscript returns the decrypted transcript of a chat for moderators
+func (c *ChatUseCase) GetTranscript(ctx context.Context, chatID string) ([]entity.TranscriptEntry, error) {
+	return c.chatService.GetTranscript(ctx, chatID)
+}
+
+// FindBan returns a ban keeping the user or client out, or nil
+func (c *ChatUseCase) FindBan(ctx context.Context, userID string, client entity.ClientInfo) *entity.Ban {
+	return c.chatService.FindBan(ctx, userID, client)
+}
+
+// ScreenQueuedUser applies bans to a waiting user, reporting whether they may be matched as they are
+func (c *ChatUseCase) ScreenQueuedUser(ctx context.Context, user entity.User) (bool, error) {
+	return c.chatService.ScreenQueuedUser(ctx, user)
+}
+
+// CreateBan bans a user, IP range or device, outright or into the shadow pool
+func (c *ChatUseCase) CreateBan(ctx context.Context, kind entity.BanKind, mode entity.BanMode, value, reason, issuedBy string, duration time.Duration) (*entity.Ban, error) {
+	return c.chatService.CreateBan(ctx, kind, mode, value, reason, issuedBy, duration)
+}
+
+// ListBans returns active bans, newest first
+func (c *ChatUseCase) ListBans(ctx context.Context, limit int) ([]entity.Ban, error) {
+	return c.chatService.ListBans(ctx, limit)
+}
+
+// LiftBan removes a ban before it expires
+func (c *ChatUseCase) LiftBan(ctx context.Context, banID, liftedBy string) error {
+	return c.chatService.LiftBan(ctx, banID, liftedBy)
+}
+
+// ListReports returns reports in the moderation queue
+func (c *ChatUseCase) ListReports(ctx context.Context, status entity.ReportStatus, limit int) ([]entity.Report, error) {
+	return c.chatService.ListReports(ctx, status, limit)
+}
+
+// GetReport retrieves a single report
+func (c *ChatUseCase) GetReport(ctx context.Context, reportID string) (*entity.Report, error) {
+	return c.chatService.GetReport(ctx, reportID)
+}
+
+// ResolveReport closes a report, banning the reported user when the action is ban
+func (c *ChatUseCase) ResolveReport(ctx context.Context, reportID string, action entity.ReportAction, note, resolvedBy string, banDuration time.Duration) (*entity.Report, error) {
+	return c.chatService.ResolveReport(ctx, reportID, action, note, resolvedBy, banDuration)
+}
+
+// HandleChatPair creates a chat session when two users are matched.
+// If it fails, both users go back in the queue instead of being left in no chat.
+// Either way the claim on the pair is released once this returns.
 func (c *ChatUseCase) HandleChatPair(ctx context.Context, userA, userB entity.User) error {
+	defer c.chatService.ReleaseClaim(ctx, userA.UserID, userB.UserID)
 
 	// Create chat session entity
 	chat := entity.Chat{
@@ -63,7 +145,23 @@ func (c *ChatUseCase) HandleChatPair(ctx context.Context, userA, userB entity.Us
 		StartTime: time.Now(),
 	}
 
+	if err := c.startChat(ctx, &chat); err != nil {
+		// The worker already claimed both users off the queue
+		c.chatService.ReturnToQueue(ctx, userA.UserID, userB.UserID)
+		return err
+	}
+	log.Printf("✅ Chat session started: %s <-> %s (ChatID: %s)", userA.UserID, userB.UserID, chat.ID)
+	return nil
+}
+
+// RecoverStaleClaims re-queues users left claimed by a worker that stopped before their chat existed
+func (c *ChatUseCase) RecoverStaleClaims(ctx context.Context) error {
+	return c.chatService.RecoverStaleClaims(ctx)
+}
 
+// startChat points both users at the chat and saves it
+func (c *ChatUseCase) startChat(ctx context.Context, chat *entity.Chat) error {
+	userA, userB := chat.UserA, chat.UserB
 	if err := c.chatService.UpdateUserChatID(ctx, userA.UserID, chat.ID); err != nil {
 		log.Printf("❌ Error updating ChatID for user %s: %v", userA.UserID, err)
 		return err
@@ -74,12 +172,11 @@ func (c *ChatUseCase) HandleChatPair(ctx context.Context, userA, userB entity.Us
 	}
 
 	// Save chat session
-	err := c.chatService.CreateChatSession(ctx, &chat)
+	err := c.chatService.CreateChatSession(ctx, chat)
 	if err != nil {
 		log.Printf("Error saving chat session: %v", err)
 		return err
 	}
-	log.Printf("✅ Chat session started: %s <-> %s (ChatID: %s)", userA.UserID, userB.UserID, chat.ID)
 	return nil
 }
 
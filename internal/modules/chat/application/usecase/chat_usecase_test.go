@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	service "github.com/royroki/LetsGo/internal/modules/chat/domain/services"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	web_socket_hub "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+)
+
+// failingChatIDs fails to point one user at their new chat
+type failingChatIDs struct {
+	repository.UserRepository
+	failFor string
+}
+
+func (r failingChatIDs) UpdateUserChatID(ctx context.Context, userID, chatID string) error {
+	if userID == r.failFor {
+		return errors.New("storage unavailable")
+	}
+	return r.UserRepository.UpdateUserChatID(ctx, userID, chatID)
+}
+
+func TestHandleChatPairRequeuesUsersWhenPairingFails(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	chats := memory.NewChatRepository()
+	hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+	useCase := NewChatUseCase(service.NewChatService(chats, failingChatIDs{UserRepository: users, failFor: "b"}, hub))
+
+	for _, userID := range []string{"a", "b"} {
+		if err := users.AddUserToQueue(ctx, entity.User{UserID: userID, JoinTime: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queued, _ := users.PeekQueue(ctx, 2)
+	if claimed, _ := users.ClaimUsers(ctx, time.Minute, "a", "b"); !claimed {
+		t.Fatal("could not claim the pair")
+	}
+
+	if err := useCase.HandleChatPair(ctx, queued[0], queued[1]); err == nil {
+		t.Fatal("pairing succeeded despite the storage failure")
+	}
+
+	// Both users are waiting again and a's half-written chat ID is gone
+	if length, _ := users.GetQueueLength(ctx); length != 2 {
+		t.Errorf("queue length = %d, want both users back", length)
+	}
+	if user, _ := users.GetUser(ctx, "a"); user == nil || user.ChatID != "" {
+		t.Errorf("user a = %+v, want no chat ID", user)
+	}
+}
+
+func TestRecoverStaleClaimsRequeuesStrandedUsers(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	chats := memory.NewChatRepository()
+	hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+	useCase := NewChatUseCase(service.NewChatService(chats, users, hub))
+
+	for _, userID := range []string{"a", "b", "c", "d"} {
+		if err := users.AddUserToQueue(ctx, entity.User{UserID: userID, JoinTime: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a and b were claimed by a worker that died; c and d were paired normally
+	users.ClaimUsers(ctx, time.Nanosecond, "a", "b")
+	users.ClaimUsers(ctx, time.Nanosecond, "c", "d")
+	userC, _ := users.GetUser(ctx, "c")
+	userD, _ := users.GetUser(ctx, "d")
+	if err := useCase.HandleChatPair(ctx, *userC, *userD); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if err := useCase.RecoverStaleClaims(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waiting, _ := users.PeekQueue(ctx, 10)
+	if len(waiting) != 2 || waiting[0].UserID == "c" || waiting[1].UserID == "c" {
+		t.Errorf("queue = %+v, want only a and b back", waiting)
+	}
+}
@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BanKind tells what a ban applies to
+type BanKind string
+
+const (
+	BanKindUser   BanKind = "user"   // An authenticated user ID
+	BanKindIP     BanKind = "ip"     // A client IP address or CIDR range
+	BanKindDevice BanKind = "device" // A client-supplied device fingerprint
+)
+
+// BanMode tells what a ban does to the client it applies to
+type BanMode string
+
+const (
+	BanModeBlock  BanMode = "block"  // Refused at connect and dropped from the queue
+	BanModeShadow BanMode = "shadow" // Let in as usual but matched only with other shadow-banned users
+)
+
+// maxBanValueLength bounds user IDs and device fingerprints in bans
+const maxBanValueLength = 128
+
+// Ban keeps a user or client from connecting and matching until it expires
+type Ban struct {
+	ID        string     `json:"id"`
+	Kind      BanKind    `json:"kind"`
+	Mode      BanMode    `json:"mode"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason,omitempty"`
+	IssuedBy  string     `json:"issued_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil bans permanently
+}
+
+// NewBan creates a ban lasting duration; zero bans permanently. An empty mode blocks.
+// IP bans accept an address or a CIDR range and are stored as a range.
+func NewBan(kind BanKind, mode BanMode, value, reason, issuedBy string, duration time.Duration) (*Ban, error) {
+	value, err := normalizeBanValue(kind, value)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "":
+		mode = BanModeBlock
+	case BanModeBlock, BanModeShadow:
+	default:
+		return nil, fmt.Errorf("unknown ban mode %q", mode)
+	}
+
+	ban := &Ban{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Mode:      mode,
+		Value:     value,
+		Reason:    reason,
+		IssuedBy:  issuedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if duration > 0 {
+		expiresAt := ban.CreatedAt.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+	return ban, nil
+}
+
+// Shadow reports whether the ban only moves its client to the shadow pool.
+// Bans stored before modes existed block.
+func (b Ban) Shadow() bool {
+	return b.Mode == BanModeShadow
+}
+
+// Outranks reports whether the ban should stand against a new one of mode lasting duration
+// (zero is permanent). A block beats a shadow ban and a permanent ban beats a timed one;
+// either is enough, and an equal ban stands too.
+func (b Ban) Outranks(mode BanMode, duration time.Duration) bool {
+	stronger := !b.Shadow() && mode == BanModeShadow
+	weaker := b.Shadow() && mode != BanModeShadow
+
+	var longer, shorter bool
+	switch {
+	case b.ExpiresAt == nil:
+		longer = duration > 0
+	case duration <= 0:
+		shorter = true
+	default:
+		until := time.Now().Add(duration)
+		longer, shorter = b.ExpiresAt.After(until), b.ExpiresAt.Before(until)
+	}
+	return stronger || longer || !(weaker || shorter)
+}
+
+// MatchesIP reports whether an IP ban covers the address
+func (b Ban) MatchesIP(ip string) bool {
+	prefix, err := netip.ParsePrefix(b.Value)
+	if err != nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return prefix.Contains(addr.Unmap())
+}
+
+// normalizeBanValue validates a ban value, turning a single IP into a one-address range
+func normalizeBanValue(kind BanKind, value string) (string, error) {
+	switch kind {
+	case BanKindUser, BanKindDevice:
+		if value == "" || len(value) > maxBanValueLength {
+			return "", fmt.Errorf("%s ban needs a value of 1 to %d characters", kind, maxBanValueLength)
+		}
+		return value, nil
+	case BanKindIP:
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			return prefix.Masked().String(), nil
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", fmt.Errorf("ip ban needs an IP address or CIDR range, got %q", value)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	default:
+		return "", fmt.Errorf("unknown ban kind %q", kind)
+	}
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"time"
+)
+
+// maxSolutionLength bounds the solution a client may submit
+const maxSolutionLength = 64
+
+// Challenge is a hashcash-style proof of work a client must solve before it is queued:
+// find a solution such that sha256(nonce + solution) starts with Difficulty zero bits
+type Challenge struct {
+	Nonce      string
+	Difficulty int
+	IssuedAt   time.Time
+}
+
+// NewChallenge creates a challenge with a random nonce
+func NewChallenge(difficulty int) Challenge {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return Challenge{
+		Nonce:      hex.EncodeToString(nonce),
+		Difficulty: difficulty,
+		IssuedAt:   time.Now(),
+	}
+}
+
+// Verify reports whether solution solves the challenge
+func (c Challenge) Verify(solution string) bool {
+	if solution == "" || len(solution) > maxSolutionLength {
+		return false
+	}
+	sum := sha256.Sum256([]byte(c.Nonce + solution))
+	return leadingZeroBits(sum[:]) >= c.Difficulty
+}
+
+// leadingZeroBits counts the zero bits at the start of a hash
+func leadingZeroBits(hash []byte) int {
+	zeros := 0
+	for _, b := range hash {
+		if b != 0 {
+			return zeros + bits.LeadingZeros8(b)
+		}
+		zeros += 8
+	}
+	return zeros
+}
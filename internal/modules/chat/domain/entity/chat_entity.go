@@ -2,6 +2,18 @@ package entity
 
 import "time"
 
+// EndReason records why a chat session ended
+type EndReason string
+
+const (
+	EndReasonDisconnected   EndReason = "disconnected"    // A user closed their connection
+	EndReasonConnectionLost EndReason = "connection_lost" // A user stopped answering heartbeats
+	EndReasonSkipped        EndReason = "skipped"         // A user skipped to the next partner
+	EndReasonRateLimited    EndReason = "rate_limited"    // A user was disconnected for flooding
+	EndReasonServerShutdown EndReason = "server_shutdown" // A user's instance drained for shutdown
+	EndReasonBanned         EndReason = "banned"          // A user was banned by a moderator
+)
+
 // Chat represents a conversation session between two users
 type Chat struct {
 	ID        string     `json:"id"`
@@ -9,4 +21,6 @@ type Chat struct {
 	UserB     User       `json:"user_b"`
 	StartTime time.Time  `json:"start_time"`
 	EndTime   *time.Time `json:"end_time,omitempty"` // Pointer to handle ongoing chats (nil if active)
+
+	MessageCounts map[string]int64 `json:"-"` // Chat messages sent per user, counted outside the snapshot
 }
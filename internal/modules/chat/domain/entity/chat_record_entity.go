@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// ChatRecord is the history kept about a chat once it ends. It holds metadata only, never message bodies.
+type ChatRecord struct {
+	ChatID    string
+	UserA     string
+	UserB     string
+	StartTime time.Time
+	EndTime   time.Time
+	EndReason EndReason
+	EndedBy   string // The user whose leave or skip ended the chat
+	MessagesA int64  // Chat messages UserA sent
+	MessagesB int64  // Chat messages UserB sent
+	NodeID    string // The instance that ended the chat
+}
+
+// NewChatRecord summarizes an ended chat
+func NewChatRecord(chat *Chat, endedBy string, reason EndReason, nodeID string) ChatRecord {
+	record := ChatRecord{
+		ChatID:    chat.ID,
+		UserA:     chat.UserA.UserID,
+		UserB:     chat.UserB.UserID,
+		StartTime: chat.StartTime,
+		EndReason: reason,
+		EndedBy:   endedBy,
+		MessagesA: chat.MessageCounts[chat.UserA.UserID],
+		MessagesB: chat.MessageCounts[chat.UserB.UserID],
+		NodeID:    nodeID,
+	}
+	if chat.EndTime != nil {
+		record.EndTime = *chat.EndTime
+	}
+	return record
+}
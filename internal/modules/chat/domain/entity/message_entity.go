@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageVersion is the current version of the message envelope
+const MessageVersion = 1
+
+// MessageType tells clients how to interpret a message
+type MessageType string
+
+const (
+	MessageTypeChat          MessageType = "chat"           // Text from the chat partner
+	MessageTypeSystem        MessageType = "system"         // Informational notice from the server
+	MessageTypePartnerJoined MessageType = "partner_joined" // A new partner was matched
+	MessageTypePartnerLeft   MessageType = "partner_left"   // The partner left or lost their connection
+	MessageTypeQueued        MessageType = "queued"         // The user is waiting for a partner
+	MessageTypeError         MessageType = "error"          // A request from the client failed
+	MessageTypeTyping        MessageType = "typing"         // The partner is typing
+	MessageTypeSkip          MessageType = "skip"           // Client command: end this chat and find a new partner
+	MessageTypeSession       MessageType = "session"        // The user's identity and resume token
+	MessageTypeReport        MessageType = "report"         // Client command: report the current partner
+	MessageTypeChallenge     MessageType = "challenge"      // Proof of work the client must solve before it is queued
+	MessageTypeSolution      MessageType = "solution"       // Client command: the solution to the challenge, in text
+
+	MessageTypePartnerReconnecting MessageType = "partner_reconnecting" // The partner dropped and may come back
+	MessageTypePartnerReconnected  MessageType = "partner_reconnected"  // The partner resumed the chat
+	MessageTypeServerRestarting    MessageType = "server_restarting"    // The server is shutting down; reconnect to continue
+)
+
+// Error codes carried by error messages
+const (
+	ErrCodeBadRequest       = "bad_request"       // The client message could not be decoded
+	ErrCodeUnsupportedType  = "unsupported_type"  // Clients may not send this message type
+	ErrCodeForwardFailed    = "forward_failed"    // The message could not be delivered to the partner
+	ErrCodeNotInChat        = "not_in_chat"       // The command needs an active chat
+	ErrCodeRateLimited      = "rate_limited"      // The client is sending faster than allowed
+	ErrCodeInvalidReason    = "invalid_reason"    // A report carried an unknown reason code
+	ErrCodeReportFailed     = "report_failed"     // The report could not be filed
+	ErrCodeBanned           = "banned"            // The user was banned
+	ErrCodeMessageBlocked   = "message_blocked"   // A content filter stopped the message; reason names the filter
+	ErrCodeBadSolution      = "invalid_solution"  // The solution does not solve the challenge
+	ErrCodeChallengeExpired = "challenge_expired" // The challenge was not solved in time
+)
+
+// Message is the envelope exchanged with clients in both directions
+type Message struct {
+	Version    int         `json:"v"`
+	ID         string      `json:"id"`
+	Type       MessageType `json:"type"`
+	From       string      `json:"from,omitempty"`       // Sender of chat and typing messages
+	UserID     string      `json:"user_id,omitempty"`    // The recipient's own ID, on session messages
+	Token      string      `json:"token,omitempty"`      // Resume token, on session messages
+	PartnerID  string      `json:"partner_id,omitempty"` // Partner a partner_* event refers to
+	Text       string      `json:"text,omitempty"`
+	Code       string      `json:"code,omitempty"`       // Machine-readable reason for error messages
+	Reason     string      `json:"reason,omitempty"`     // Reason code, on report commands and filter errors
+	Nonce      string      `json:"nonce,omitempty"`      // Challenge nonce, on challenge messages
+	Difficulty int         `json:"difficulty,omitempty"` // Leading zero bits the solution must produce, on challenge messages
+	Timestamp  time.Time   `json:"ts"`
+}
+
+// NewMessage creates a message stamped with a fresh ID and the server time
+func NewMessage(msgType MessageType, text string) *Message {
+	return &Message{
+		Version:   MessageVersion,
+		ID:        uuid.New().String(),
+		Type:      msgType,
+		Text:      text,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// NewChatMessage creates a chat message sent by `from`
+func NewChatMessage(from, text string) *Message {
+	msg := NewMessage(MessageTypeChat, text)
+	msg.From = from
+	return msg
+}
+
+// NewPartnerMessage creates a partner_joined or partner_left event about `partnerID`
+func NewPartnerMessage(msgType MessageType, partnerID, text string) *Message {
+	msg := NewMessage(msgType, text)
+	msg.PartnerID = partnerID
+	return msg
+}
+
+// NewSessionMessage tells a user their ID and the token that lets them resume it
+func NewSessionMessage(userID, token, text string) *Message {
+	msg := NewMessage(MessageTypeSession, text)
+	msg.UserID = userID
+	msg.Token = token
+	return msg
+}
+
+// NewChallengeMessage asks the client to solve a proof of work challenge
+func NewChallengeMessage(challenge Challenge, text string) *Message {
+	msg := NewMessage(MessageTypeChallenge, text)
+	msg.Nonce = challenge.Nonce
+	msg.Difficulty = challenge.Difficulty
+	return msg
+}
+
+// NewErrorMessage creates an error message with a machine-readable code
+func NewErrorMessage(code, text string) *Message {
+	msg := NewMessage(MessageTypeError, text)
+	msg.Code = code
+	return msg
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReportReason is the reason code a user gives when reporting their partner
+type ReportReason string
+
+const (
+	ReportReasonSpam       ReportReason = "spam"
+	ReportReasonHarassment ReportReason = "harassment"
+	ReportReasonSexual     ReportReason = "sexual_content"
+	ReportReasonHate       ReportReason = "hate"
+	ReportReasonUnderage   ReportReason = "underage"
+	ReportReasonOther      ReportReason = "other"
+)
+
+// Valid reports whether the reason is one clients may send
+func (r ReportReason) Valid() bool {
+	switch r {
+	case ReportReasonSpam, ReportReasonHarassment, ReportReasonSexual, ReportReasonHate, ReportReasonUnderage, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+// SystemReporterID is the reporter of reports the server files itself, such as flagged messages
+const SystemReporterID = "system"
+
+// ReportStatus tracks a report through moderation
+type ReportStatus string
+
+const (
+	ReportStatusOpen     ReportStatus = "open"     // Waiting for a moderator
+	ReportStatusResolved ReportStatus = "resolved" // A moderator decided on it
+)
+
+// ReportAction is what a moderator decided to do about a report
+type ReportAction string
+
+const (
+	ReportActionDismiss ReportAction = "dismiss" // No action against the reported user
+	ReportActionBan     ReportAction = "ban"     // The reported user is banned
+)
+
+// ReportResolution records a moderator's decision
+type ReportResolution struct {
+	Action     ReportAction `json:"action"`
+	Note       string       `json:"note,omitempty"`
+	BanID      string       `json:"ban_id,omitempty"` // Set when the action banned the reported user
+	ResolvedBy string       `json:"resolved_by"`
+	ResolvedAt time.Time    `json:"resolved_at"`
+}
+
+// Report is a user's complaint about their chat partner
+type Report struct {
+	ID         string            `json:"id"`
+	ChatID     string            `json:"chat_id"`
+	ReporterID string            `json:"reporter_id"`
+	ReportedID string            `json:"reported_id"`
+	Reason     ReportReason      `json:"reason"`
+	Comment    string            `json:"comment,omitempty"`
+	Messages   []TranscriptEntry `json:"messages,omitempty"` // Recent messages of the chat, when captured
+	NodeID     string            `json:"node_id"`
+	Status     ReportStatus      `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Resolution *ReportResolution `json:"resolution,omitempty"`
+}
+
+// NewReport creates an open report
+func NewReport(chatID, reporterID, reportedID string, reason ReportReason, comment string) *Report {
+	return &Report{
+		ID:         uuid.New().String(),
+		ChatID:     chatID,
+		ReporterID: reporterID,
+		ReportedID: reportedID,
+		Reason:     reason,
+		Comment:    comment,
+		Status:     ReportStatusOpen,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
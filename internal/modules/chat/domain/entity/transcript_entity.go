@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// TranscriptEntry is one chat message kept in a transcript for moderation
+type TranscriptEntry struct {
+	MessageID string    `json:"id"`
+	From      string    `json:"from"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"ts"`
+}
+
+// NewTranscriptEntry captures a forwarded chat message
+func NewTranscriptEntry(message *Message) TranscriptEntry {
+	return TranscriptEntry{
+		MessageID: message.ID,
+		From:      message.From,
+		Text:      message.Text,
+		Timestamp: message.Timestamp,
+	}
+}
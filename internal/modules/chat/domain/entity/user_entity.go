@@ -1,11 +1,47 @@
 package entity
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // User represents a connected user in the chat system
 type User struct {
-	UserID   string    `json:"user_id"`
-	ChatID   string    `json:"chat_id"`
-	JoinTime time.Time `json:"join_time"`
-	Chatted  int64     `json:"chatted"`
+	UserID   string     `json:"user_id"`
+	ChatID   string     `json:"chat_id"`
+	JoinTime time.Time  `json:"join_time"`
+	Chatted  int64      `json:"chatted"`
+	Tags     []string   `json:"tags,omitempty"`   // Interest tags used for matchmaking
+	QueuedAt time.Time  `json:"queued_at"`        // When the user last entered the waiting queue
+	Client   ClientInfo `json:"client"`           // Where the user connected from, checked against bans
+	Shadow   bool       `json:"shadow,omitempty"` // Shadow-banned: matched only with other shadow-banned users
+}
+
+// ClientInfo identifies where a user connects from
+type ClientInfo struct {
+	IP       string `json:"ip,omitempty"`
+	DeviceID string `json:"device_id,omitempty"` // Client-supplied fingerprint; easy to change, so only one signal among several
+}
+
+// NormalizeTags lowercases, trims and de-duplicates interest tags,
+// keeping at most maxTags tags of at most maxLen characters each.
+func NormalizeTags(raw []string, maxTags, maxLen int) []string {
+	seen := make(map[string]struct{}, len(raw))
+	tags := make([]string, 0, len(raw))
+
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxLen || strings.ContainsAny(tag, ", ") {
+			continue
+		}
+		if _, exists := seen[tag]; exists {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+		if len(tags) == maxTags {
+			break
+		}
+	}
+	return tags
 }
@@ -0,0 +1,67 @@
+package filter
+
+import "regexp"
+
+// linkPattern matches URLs and bare domains with a common top-level domain
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|co|me|ly|gg|app|dev|info|biz|xyz|ru|de|uk|tv|link|site|online)\b`)
+
+// phonePattern matches runs of digits with common phone number separators
+var phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().-]{5,}\d`)
+
+// minPhoneDigits is how many digits make a run look like a phone number rather than, say, a range of years
+const minPhoneDigits = 9
+
+// links blocks messages containing URLs
+type links struct{}
+
+// BlockLinks blocks messages containing URLs or domain names
+func BlockLinks() Filter {
+	return links{}
+}
+
+// Name identifies the filter
+func (links) Name() string {
+	return "links"
+}
+
+// Apply blocks messages with a link
+func (links) Apply(text string) Verdict {
+	if linkPattern.MatchString(text) {
+		return Verdict{Action: Block, Reason: "Links are not allowed."}
+	}
+	return Verdict{Action: Pass}
+}
+
+// phoneNumbers blocks messages containing phone numbers
+type phoneNumbers struct{}
+
+// BlockPhoneNumbers blocks messages containing something that looks like a phone number
+func BlockPhoneNumbers() Filter {
+	return phoneNumbers{}
+}
+
+// Name identifies the filter
+func (phoneNumbers) Name() string {
+	return "phone_numbers"
+}
+
+// Apply blocks messages with a phone number
+func (phoneNumbers) Apply(text string) Verdict {
+	for _, match := range phonePattern.FindAllString(text, -1) {
+		if countDigits(match) >= minPhoneDigits {
+			return Verdict{Action: Block, Reason: "Phone numbers are not allowed."}
+		}
+	}
+	return Verdict{Action: Pass}
+}
+
+// countDigits counts the ASCII digits in s
+func countDigits(s string) int {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits
+}
@@ -0,0 +1,75 @@
+package filter
+
+// Action is what a filter decides to do with a message
+type Action int
+
+const (
+	Pass    Action = iota // Leave the message as it is
+	Rewrite               // Replace the message text
+	Block                 // Drop the message and tell the sender
+	Flag                  // Deliver the message but queue it for moderators
+)
+
+// Verdict is a filter's decision about one message
+type Verdict struct {
+	Action Action
+	Text   string // New text, when rewriting
+	Reason string // Why the message was blocked or flagged, shown to the sender or moderators
+}
+
+// Filter inspects the text of a chat message before it reaches the partner
+type Filter interface {
+	// Name identifies the filter in errors, logs and metrics
+	Name() string
+
+	// Apply decides what happens to the message text
+	Apply(text string) Verdict
+}
+
+// Finding is a block or flag raised by one filter
+type Finding struct {
+	Filter string
+	Reason string
+}
+
+// Outcome is the result of running a message through a chain
+type Outcome struct {
+	Text      string    // Text to deliver, after every rewrite
+	Rewritten []string  // Filters that rewrote the text
+	Blocked   *Finding  // Set when a filter blocked the message
+	Flags     []Finding // Flags raised before any block
+}
+
+// Chain runs filters in order. A rewrite is seen by the filters after it,
+// a block stops the chain, and flags accumulate.
+type Chain []Filter
+
+// NewChain builds a chain from filters, skipping nil ones so disabled filters can be passed as is
+func NewChain(filters ...Filter) Chain {
+	chain := make(Chain, 0, len(filters))
+	for _, f := range filters {
+		if f != nil {
+			chain = append(chain, f)
+		}
+	}
+	return chain
+}
+
+// Run passes text through every filter in order
+func (c Chain) Run(text string) Outcome {
+	outcome := Outcome{Text: text}
+	for _, f := range c {
+		verdict := f.Apply(outcome.Text)
+		switch verdict.Action {
+		case Rewrite:
+			outcome.Text = verdict.Text
+			outcome.Rewritten = append(outcome.Rewritten, f.Name())
+		case Block:
+			outcome.Blocked = &Finding{Filter: f.Name(), Reason: verdict.Reason}
+			return outcome
+		case Flag:
+			outcome.Flags = append(outcome.Flags, Finding{Filter: f.Name(), Reason: verdict.Reason})
+		}
+	}
+	return outcome
+}
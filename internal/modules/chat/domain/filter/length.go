@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// maxLength blocks messages longer than a number of characters
+type maxLength struct {
+	limit int
+}
+
+// MaxLength blocks messages longer than limit characters; a limit of 0 or less disables it
+func MaxLength(limit int) Filter {
+	if limit <= 0 {
+		return nil
+	}
+	return maxLength{limit: limit}
+}
+
+// Name identifies the filter
+func (f maxLength) Name() string {
+	return "max_length"
+}
+
+// Apply blocks messages over the limit
+func (f maxLength) Apply(text string) Verdict {
+	if utf8.RuneCountInString(text) > f.limit {
+		return Verdict{Action: Block, Reason: fmt.Sprintf("Messages may be at most %d characters.", f.limit)}
+	}
+	return Verdict{Action: Pass}
+}
+
+// validUTF8 blocks text that is not valid UTF-8
+type validUTF8 struct{}
+
+// ValidUTF8 blocks messages that are not valid UTF-8, which raw-text clients can send
+func ValidUTF8() Filter {
+	return validUTF8{}
+}
+
+// Name identifies the filter
+func (validUTF8) Name() string {
+	return "utf8"
+}
+
+// Apply blocks malformed text
+func (validUTF8) Apply(text string) Verdict {
+	if !utf8.ValidString(text) {
+		return Verdict{Action: Block, Reason: "Messages must be valid UTF-8."}
+	}
+	return Verdict{Action: Pass}
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// wordPattern finds the words a message is made of
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// wordList matches whole words from a list, ignoring case
+type wordList struct {
+	name  string
+	words map[string]struct{}
+}
+
+// Profanity masks listed words with asterisks; an empty list disables it
+func Profanity(words []string) Filter {
+	list := newWordList("profanity", words)
+	if list == nil {
+		return nil
+	}
+	return profanity{list}
+}
+
+// WatchWords flags messages containing listed words for moderators; an empty list disables it
+func WatchWords(words []string) Filter {
+	list := newWordList("watch_words", words)
+	if list == nil {
+		return nil
+	}
+	return watchWords{list}
+}
+
+// newWordList builds a word set, or nil for an empty list
+func newWordList(name string, words []string) *wordList {
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			set[word] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return &wordList{name: name, words: set}
+}
+
+// Name identifies the filter
+func (f *wordList) Name() string {
+	return f.name
+}
+
+// listed reports whether a word is on the list
+func (f *wordList) listed(word string) bool {
+	_, ok := f.words[strings.ToLower(word)]
+	return ok
+}
+
+// profanity rewrites listed words
+type profanity struct{ *wordList }
+
+// Apply masks every listed word, keeping the message length
+func (f profanity) Apply(text string) Verdict {
+	masked := false
+	text = wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if !f.listed(word) {
+			return word
+		}
+		masked = true
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+	if !masked {
+		return Verdict{Action: Pass}
+	}
+	return Verdict{Action: Rewrite, Text: text}
+}
+
+// watchWords flags listed words
+type watchWords struct{ *wordList }
+
+// Apply flags the first listed word found
+func (f watchWords) Apply(text string) Verdict {
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		if f.listed(word) {
+			return Verdict{Action: Flag, Reason: "contains watched word " + strings.ToLower(word)}
+		}
+	}
+	return Verdict{Action: Pass}
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// AbuseScoreRepository keeps per-user abuse scores shared by all instances.
+// A score halves every halfLife, so old misbehaviour fades on its own.
+type AbuseScoreRepository interface {
+	// AddScore adds points to the user's decayed score and returns the new score
+	AddScore(ctx context.Context, userID string, points float64, halfLife time.Duration) (float64, error)
+
+	// GetScore returns the user's score decayed to now without writing anything; unknown users score 0
+	GetScore(ctx context.Context, userID string, halfLife time.Duration) (float64, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// ErrBanNotFound is returned when lifting a ban that does not exist or has expired
+var ErrBanNotFound = errors.New("ban not found")
+
+// BanRepository stores bans until they expire or are lifted
+type BanRepository interface {
+	// CreateBan stores a ban, replacing any earlier user or device ban on the same value, whatever its mode
+	CreateBan(ctx context.Context, ban *entity.Ban) error
+
+	// FindBan returns the active ban on a kind and value, or nil when there is none.
+	// For IP bans the value is an address, matched against every banned range; a blocking range wins over a shadow one.
+	FindBan(ctx context.Context, kind entity.BanKind, value string) (*entity.Ban, error)
+
+	// ListBans returns up to limit active bans, newest first
+	ListBans(ctx context.Context, limit int) ([]entity.Ban, error)
+
+	// DeleteBan lifts a ban
+	DeleteBan(ctx context.Context, banID string) error
+}
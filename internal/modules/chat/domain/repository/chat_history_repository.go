@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// ChatHistoryRepository keeps a durable record of ended chats
+type ChatHistoryRepository interface {
+	// RecordChat stores an ended chat; recording the same chat twice keeps the first record
+	RecordChat(ctx context.Context, record entity.ChatRecord) error
+}
@@ -17,11 +17,15 @@ type ChatRepository interface {
 	// Get the chat partner for a user
 	GetChatPartner(ctx context.Context, chatID, userID string) (*entity.User, error)
 
-	// Delete a chat session from storage
-	DeleteChatSession(ctx context.Context, chatID string) error
+	// Count a chat message sent by userID; a chat that already ended is ignored
+	IncrementMessageCount(ctx context.Context, chatID, userID string) error
+
+	// Delete a chat session from storage, reporting whether this call removed it.
+	// Only the caller that removed a chat may end it, so racing users never end it twice.
+	DeleteChatSession(ctx context.Context, chatID string) (bool, error)
 
 	// Subcribe for chat updates
-	SubscribeToChatUpdates(ctx context.Context, userID string) <-chan *entity.User 
+	SubscribeToChatUpdates(ctx context.Context, userID string) <-chan *entity.User
 
 	// Notify the chat updates
 	NotifyPartnerUpdate(ctx context.Context, userID string, partner *entity.User)
@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+
+// Connection is a transport-neutral client connection. Each transport provides
+// an adapter, so the domain never depends on a particular WebSocket library.
+type Connection interface {
+	// ReadMessage blocks until the client sends a message. It fails with ErrInvalidMessage
+	// for an undecodable frame, ErrConnectionLost when the client went silent and
+	// ErrClosedByClient when the client closed the connection deliberately.
+	ReadMessage() (*entity.Message, error)
+
+	// WriteMessage delivers a message to the client. Callers never write concurrently.
+	WriteMessage(message *entity.Message) error
+
+	// Close closes the underlying transport; it is safe to call more than once
+	Close() error
+
+	// RemoteAddr returns the network address of the client
+	RemoteAddr() string
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitRepository counts events in a sliding window shared by all instances
+type RateLimitRepository interface {
+	// Allow records `cost` units against `key` and reports whether the window stays within `limit`.
+	// Rejected events are not recorded.
+	Allow(ctx context.Context, key string, limit int64, window time.Duration, cost int64) (bool, error)
+
+	// Hit records one event against `key` and returns how many the sliding window holds, this one included
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+}
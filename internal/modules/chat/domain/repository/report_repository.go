@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// Errors returned by ReportRepository
+var (
+	ErrReportNotFound  = errors.New("report not found")
+	ErrReportResolved  = errors.New("report already resolved")
+	ErrDuplicateReport = errors.New("chat already reported by this user")
+)
+
+// ReportRepository is the moderation queue of user reports
+type ReportRepository interface {
+	// CreateReport queues an open report; a reporter may report each chat once
+	CreateReport(ctx context.Context, report *entity.Report) error
+
+	// GetReport retrieves a report by ID
+	GetReport(ctx context.Context, reportID string) (*entity.Report, error)
+
+	// ListReports returns up to limit reports with the given status, oldest open or newest resolved first
+	ListReports(ctx context.Context, status entity.ReportStatus, limit int) ([]entity.Report, error)
+
+	// ResolveReport closes an open report with a moderator's decision
+	ResolveReport(ctx context.Context, reportID string, resolution entity.ReportResolution) (*entity.Report, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SessionRepository tracks users who dropped their connection but may still resume their session
+type SessionRepository interface {
+	// Suspend keeps the user's session resumable for the grace period
+	Suspend(ctx context.Context, userID string, grace time.Duration) error
+
+	// Resume claims a suspended session, reporting false if it expired or was never suspended
+	Resume(ctx context.Context, userID string) (bool, error)
+
+	// Expire claims a suspended session for teardown, reporting false if the user already resumed
+	Expire(ctx context.Context, userID string) (bool, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// ErrTranscriptNotFound is returned when no transcript is kept for a chat
+var ErrTranscriptNotFound = errors.New("transcript not found")
+
+// TranscriptRepository keeps encrypted chat transcripts for a limited time
+type TranscriptRepository interface {
+	// AppendTranscript adds entries to a chat's transcript and keeps it for retention from now
+	AppendTranscript(ctx context.Context, chatID string, entries []entity.TranscriptEntry, retention time.Duration) error
+
+	// GetTranscript returns a chat's entries in the order they were appended
+	GetTranscript(ctx context.Context, chatID string) ([]entity.TranscriptEntry, error)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 )
@@ -12,5 +13,38 @@ type UserRepository interface {
 	GetUser(ctx context.Context, userID string) (*entity.User, error)
 	PopTopUsers(ctx context.Context, i int) ([]entity.User, error)
 	RemoveUser(ctx context.Context, userID string) error
-	GetQueueLength(ctx context.Context) (int, error)	
+	GetQueueLength(ctx context.Context) (int, error)
+
+	// PeekQueue returns up to limit of the oldest waiting users without removing them
+	PeekQueue(ctx context.Context, limit int) ([]entity.User, error)
+
+	// FindTagPartners returns up to limit users sharing an interest tag with user, longest-waiting first
+	FindTagPartners(ctx context.Context, user entity.User, limit int) ([]entity.User, error)
+
+	// RemoveFromQueue takes users out of the global and per-tag queues
+	RemoveFromQueue(ctx context.Context, userIDs ...string) error
+
+	// ClaimUsers atomically removes all users from the queues, or none if any is no longer waiting.
+	// It reports whether the claim succeeded, so concurrent workers never pair the same user twice.
+	// A successful claim is recorded until ttl passes or ReleaseClaim clears it.
+	ClaimUsers(ctx context.Context, ttl time.Duration, userIDs ...string) (bool, error)
+
+	// ReleaseClaim clears the claim record of users whose pairing has finished, either way
+	ReleaseClaim(ctx context.Context, userIDs ...string) error
+
+	// TakeExpiredClaims removes and returns the users of every claim whose ttl ran out by now,
+	// one slice per claim. Each claim is returned to one caller only.
+	TakeExpiredClaims(ctx context.Context, now time.Time) ([][]string, error)
+
+	// SetShadow moves a known user in or out of the shadow matchmaking pool
+	SetShadow(ctx context.Context, userID string, shadow bool) error
+
+	// MarkSkipped keeps two users from being matched with each other for ttl
+	MarkSkipped(ctx context.Context, userA, userB string, ttl time.Duration) error
+
+	// IsSkipped reports whether two users recently skipped each other
+	IsSkipped(ctx context.Context, userA, userB string) (bool, error)
+
+	// SubscribeToQueue signals whenever a user joins the waiting queue
+	SubscribeToQueue(ctx context.Context) <-chan struct{}
 }
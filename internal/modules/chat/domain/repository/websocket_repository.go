@@ -1,12 +1,26 @@
 package repository
 
-import "github.com/gorilla/websocket"
+import (
+	"errors"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// ErrInvalidMessage is returned by ReadMessage when a frame arrived but could not be decoded
+var ErrInvalidMessage = errors.New("invalid message")
+
+// ErrConnectionLost is returned by ReadMessage when the client stopped answering heartbeats
+var ErrConnectionLost = errors.New("connection lost")
+
+// ErrClosedByClient is returned by ReadMessage when the client deliberately closed the connection
+var ErrClosedByClient = errors.New("connection closed by client")
 
 // WebSocketRepository defines WebSocket operations
 type WebSocketRepository interface {
-	AddConnection(userID string, conn *websocket.Conn)
+	AddConnection(userID string, conn Connection)
 	RemoveConnection(userID string)
-	GetConnection(userID string) *websocket.Conn
-	SendMessage(userID string, message []byte) error
+	GetConnection(userID string) Connection
+	SendMessage(userID string, message *entity.Message) error
+	ReadMessage(userID string) (*entity.Message, error)
 	Shutdown()
 }
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// abuseScorerID is recorded as the issuer of bans the abuse scorer places
+const abuseScorerID = "abuse-scorer"
+
+// abuseEvent is something a user did that counts towards their abuse score
+type abuseEvent string
+
+const (
+	abuseRateViolation  abuseEvent = "rate_violation"  // A message was rejected by the rate limits
+	abuseRepeatedText   abuseEvent = "repeated_text"   // The same message was sent to another partner
+	abuseSkippedAtOnce  abuseEvent = "skipped_at_once" // A partner skipped within a few seconds
+	abuseReportReceived abuseEvent = "report_received" // A partner reported the user
+	abuseFilterBlock    abuseEvent = "filter_block"    // A content filter blocked a message
+	abuseFilterFlag     abuseEvent = "filter_flag"     // A content filter flagged a message
+)
+
+// abuseWeights is how many points each event adds to a score
+var abuseWeights = map[abuseEvent]float64{
+	abuseRateViolation:  2,
+	abuseRepeatedText:   5,
+	abuseSkippedAtOnce:  3,
+	abuseReportReceived: 15,
+	abuseFilterBlock:    3,
+	abuseFilterFlag:     10,
+}
+
+// Repeated text detection
+const (
+	minRepeatedRunes = 12  // Shorter messages, like greetings, are repeated by everyone
+	maxTrackedTexts  = 100 // Texts remembered per user before starting over
+)
+
+// AbuseScoring configures automatic action on abuse scores; a zero threshold disables its action
+type AbuseScoring struct {
+	HalfLife       time.Duration // How long a score takes to halve
+	ShortChat      time.Duration // Chats skipped sooner count against the skipped user
+	ThrottleAt     float64       // Score from which messages are throttled
+	ThrottleRate   int           // Messages per minute allowed while throttled
+	ShadowAt       float64       // Score from which the user is shadow-banned
+	ShadowDuration time.Duration
+	BanAt          float64 // Score from which the user is banned
+	BanDuration    time.Duration
+}
+
+// WithAbuseScoring scores users on the events the chat flow produces and throttles,
+// shadow-bans or bans them as their score crosses the thresholds. Bans need moderation.
+func WithAbuseScoring(scoreRepo repository.AbuseScoreRepository, scoring AbuseScoring) Option {
+	return func(s *ChatService) {
+		if scoring.HalfLife > 0 {
+			s.abuseRepo = scoreRepo
+			s.abuse = scoring
+		}
+	}
+}
+
+// scoreAbuse adds an event to a user's abuse score and acts on the thresholds it crosses.
+// Each action is taken once, when the score first climbs past its threshold.
+func (s *ChatService) scoreAbuse(ctx context.Context, userID string, event abuseEvent) {
+	if s.abuseRepo == nil {
+		return
+	}
+
+	points := abuseWeights[event]
+	score, err := s.abuseRepo.AddScore(ctx, userID, points, s.abuse.HalfLife)
+	if err != nil {
+		return
+	}
+	metrics.AbuseEvents.WithLabelValues(string(event)).Inc()
+	crossed := func(threshold float64) bool {
+		return threshold > 0 && score >= threshold && score-points < threshold
+	}
+
+	switch {
+	case crossed(s.abuse.BanAt):
+		s.banForAbuse(ctx, userID, entity.BanModeBlock, score, s.abuse.BanDuration)
+	case crossed(s.abuse.ShadowAt):
+		s.banForAbuse(ctx, userID, entity.BanModeShadow, score, s.abuse.ShadowDuration)
+	case crossed(s.abuse.ThrottleAt):
+		log.Printf("🐢 Throttling %s at abuse score %.1f", userID, score)
+	}
+}
+
+// banTarget is a value an abuse ban is placed on
+type banTarget struct {
+	kind  entity.BanKind
+	value string
+}
+
+// banForAbuse bans a user, and the device they connected from, without a moderator.
+// Anonymous users get a new ID on every connection, so the device ban is what sticks.
+// A ban replaces the earlier one on the same value, so stronger or longer bans are left alone.
+func (s *ChatService) banForAbuse(ctx context.Context, userID string, mode entity.BanMode, score float64, duration time.Duration) {
+	if s.banRepo == nil {
+		return
+	}
+	reason := fmt.Sprintf("abuse score %.1f", score)
+	log.Printf("🤖 Abuse score of %s reached %.1f, applying %s ban for %s", userID, score, mode, duration)
+
+	// Look the device up first: a blocking ban ends the session and forgets the user
+	var targets []banTarget
+	user, _ := s.userRepo.GetUser(ctx, userID)
+	if user != nil && user.Client.DeviceID != "" {
+		targets = append(targets, banTarget{entity.BanKindDevice, user.Client.DeviceID})
+	}
+	targets = append(targets, banTarget{entity.BanKindUser, userID})
+
+	for _, target := range targets {
+		existing, err := s.banRepo.FindBan(ctx, target.kind, target.value)
+		if err != nil {
+			log.Printf("⚠️ Failed to read %s ban on %s: %v", target.kind, target.value, err)
+			continue
+		}
+		if existing != nil && existing.Outranks(mode, duration) {
+			log.Printf("🤖 Keeping %s ban %s on %s %s", existing.Mode, existing.ID, target.kind, target.value)
+			continue
+		}
+		if _, err := s.CreateBan(ctx, target.kind, mode, target.value, reason, abuseScorerID, duration); err != nil {
+			log.Printf("⚠️ Failed to ban %s %s: %v", target.kind, target.value, err)
+		}
+	}
+}
+
+// throttled reports whether a user's abuse score has reached the throttle threshold
+func (s *ChatService) throttled(ctx context.Context, userID string) bool {
+	if s.abuseRepo == nil || s.abuse.ThrottleAt <= 0 {
+		return false
+	}
+	score, err := s.abuseRepo.GetScore(ctx, userID, s.abuse.HalfLife)
+	return err == nil && score >= s.abuse.ThrottleAt
+}
+
+// scoreShortChat counts a chat skipped soon after it started against the skipped user
+func (s *ChatService) scoreShortChat(ctx context.Context, chat *entity.Chat, skippedID string) {
+	if s.abuse.ShortChat > 0 && time.Since(chat.StartTime) < s.abuse.ShortChat {
+		s.scoreAbuse(ctx, skippedID, abuseSkippedAtOnce)
+	}
+}
+
+// repeatTracker remembers which chats a user sent each text to
+type repeatTracker struct {
+	mu    sync.Mutex
+	texts map[string]map[uint64]string // Text hash to the last chat it was sent in, per user
+}
+
+// scoreRepeatedText counts a message already sent, word for word, to an earlier partner
+func (s *ChatService) scoreRepeatedText(ctx context.Context, userID, chatID, text string) {
+	if s.abuseRepo == nil {
+		return
+	}
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if utf8.RuneCountInString(text) < minRepeatedRunes {
+		return
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	key := hash.Sum64()
+
+	s.repeats.mu.Lock()
+	texts := s.repeats.texts[userID]
+	if texts == nil || len(texts) >= maxTrackedTexts {
+		texts = make(map[uint64]string)
+		s.repeats.texts[userID] = texts
+	}
+	lastChat, seen := texts[key]
+	texts[key] = chatID
+	s.repeats.mu.Unlock()
+
+	if seen && lastChat != chatID {
+		s.scoreAbuse(ctx, userID, abuseRepeatedText)
+	}
+}
+
+// forgetRepeats drops the texts remembered for a user once their connection ends
+func (s *ChatService) forgetRepeats(userID string) {
+	s.repeats.mu.Lock()
+	defer s.repeats.mu.Unlock()
+	delete(s.repeats.texts, userID)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	web_socket_hub "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+)
+
+// memoryBans keeps one ban per kind and value, replacing it like the Redis repository
+type memoryBans struct {
+	repository.BanRepository
+	mu   sync.Mutex
+	bans map[string]*entity.Ban
+}
+
+func (r *memoryBans) CreateBan(ctx context.Context, ban *entity.Ban) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bans[string(ban.Kind)+":"+ban.Value] = ban
+	return nil
+}
+
+func (r *memoryBans) FindBan(ctx context.Context, kind entity.BanKind, value string) (*entity.Ban, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bans[string(kind)+":"+value], nil
+}
+
+func TestAbuseBanKeepsStrongerBans(t *testing.T) {
+	tests := []struct {
+		name         string
+		existingMode entity.BanMode
+		existingFor  time.Duration // Zero is permanent
+		autoMode     entity.BanMode
+		autoFor      time.Duration
+		wantKept     bool
+	}{
+		{"permanent block survives a timed block", entity.BanModeBlock, 0, entity.BanModeBlock, time.Hour, true},
+		{"block survives a longer shadow ban", entity.BanModeBlock, time.Hour, entity.BanModeShadow, 24 * time.Hour, true},
+		{"longer shadow ban survives a block", entity.BanModeShadow, 0, entity.BanModeBlock, time.Hour, true},
+		{"longer block survives a block", entity.BanModeBlock, 24 * time.Hour, entity.BanModeBlock, time.Hour, true},
+		{"shorter shadow ban is replaced by a block", entity.BanModeShadow, time.Hour, entity.BanModeBlock, 24 * time.Hour, false},
+		{"timed ban is replaced by a permanent one", entity.BanModeBlock, time.Hour, entity.BanModeBlock, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			users := memory.NewUserRepository()
+			bans := &memoryBans{bans: make(map[string]*entity.Ban)}
+			hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+			s := NewChatService(memory.NewChatRepository(), users, hub, WithModeration(nil, bans, 0))
+
+			user := entity.User{UserID: "a", JoinTime: time.Now(), Client: entity.ClientInfo{DeviceID: "device-1"}}
+			if err := users.AddUserToQueue(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			// Stored directly, so the moderator's block does not evict the user before the scorer acts
+			var moderatorBans []*entity.Ban
+			for _, target := range []banTarget{{entity.BanKindUser, "a"}, {entity.BanKindDevice, "device-1"}} {
+				ban, err := entity.NewBan(target.kind, test.existingMode, target.value, "moderator", "mod", test.existingFor)
+				if err != nil {
+					t.Fatal(err)
+				}
+				bans.CreateBan(ctx, ban)
+				moderatorBans = append(moderatorBans, ban)
+			}
+
+			s.banForAbuse(ctx, "a", test.autoMode, 100, test.autoFor)
+
+			for _, moderatorBan := range moderatorBans {
+				ban, _ := bans.FindBan(ctx, moderatorBan.Kind, moderatorBan.Value)
+				if kept := ban.ID == moderatorBan.ID; kept != test.wantKept {
+					t.Errorf("%s ban by %s, want moderator ban kept = %v", moderatorBan.Kind, ban.IssuedBy, test.wantKept)
+				}
+			}
+		})
+	}
+}
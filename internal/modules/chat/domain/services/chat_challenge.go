@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// ProofOfWork configures the challenge new users solve before they are queued
+type ProofOfWork struct {
+	Difficulty      int           // Leading zero bits asked of every client; 0 disables challenges
+	MaxDifficulty   int           // Ceiling for the raised difficulty
+	FreeConnections int           // Connections per client IP per minute before difficulty rises; 0 ignores the IP
+	FreeQueueLength int           // Waiting users before difficulty rises; 0 ignores the queue
+	Timeout         time.Duration // How long a client has to solve its challenge
+}
+
+// pendingChallenge is a connected user waiting to prove their work
+type pendingChallenge struct {
+	challenge entity.Challenge
+	user      entity.User
+	timer     *time.Timer // Disconnects the user when the challenge expires
+}
+
+// challengeSet holds the unsolved challenges of users connected to this instance
+type challengeSet struct {
+	mu      sync.Mutex
+	pending map[string]*pendingChallenge
+}
+
+// WithProofOfWork makes new users solve a proof of work challenge before they are queued
+func WithProofOfWork(pow ProofOfWork) Option {
+	return func(s *ChatService) {
+		if pow.Difficulty > 0 {
+			s.pow = pow
+		}
+	}
+}
+
+// JoinQueue queues a newly connected user, challenging them first when proof of work is on.
+// The user is only queued once their read loop receives a valid solution.
+func (s *ChatService) JoinQueue(ctx context.Context, user entity.User) error {
+	if s.pow.Difficulty <= 0 {
+		return s.AddUserToQueue(ctx, user)
+	}
+
+	challenge := entity.NewChallenge(s.challengeDifficulty(ctx, user.Client.IP))
+	pending := &pendingChallenge{challenge: challenge, user: user}
+
+	s.challenges.mu.Lock()
+	if previous := s.challenges.pending[user.UserID]; previous != nil {
+		previous.timer.Stop()
+	}
+	pending.timer = time.AfterFunc(s.pow.Timeout, func() { s.expireChallenge(user.UserID, pending) })
+	s.challenges.pending[user.UserID] = pending
+	s.challenges.mu.Unlock()
+
+	text := fmt.Sprintf("🧮 Solve challenge %s at difficulty %d to join the queue.", challenge.Nonce, challenge.Difficulty)
+	return s.wsRepo.SendMessage(user.UserID, entity.NewChallengeMessage(challenge, text))
+}
+
+// challengeDifficulty raises the base difficulty by one bit for every doubling of the client's
+// connection rate over its free allowance, and again for the queue length
+func (s *ChatService) challengeDifficulty(ctx context.Context, clientIP string) int {
+	difficulty := s.pow.Difficulty
+
+	if s.rateLimiter != nil && clientIP != "" && s.pow.FreeConnections > 0 {
+		if connections, err := s.rateLimiter.Hit(ctx, "pow:"+clientIP, time.Minute); err == nil {
+			difficulty += doublings(connections, s.pow.FreeConnections)
+		}
+	}
+	if s.pow.FreeQueueLength > 0 {
+		if length, err := s.userRepo.GetQueueLength(ctx); err == nil {
+			difficulty += doublings(int64(length), s.pow.FreeQueueLength)
+		}
+	}
+	return min(difficulty, s.pow.MaxDifficulty)
+}
+
+// doublings counts how many times count has doubled past free
+func doublings(count int64, free int) int {
+	n := 0
+	for limit := int64(free); count > limit; limit *= 2 {
+		n++
+	}
+	return n
+}
+
+// handleSolution checks a solution and queues the user once it solves their challenge
+func (s *ChatService) handleSolution(ctx context.Context, userID string, message *entity.Message) {
+	s.challenges.mu.Lock()
+	pending := s.challenges.pending[userID]
+	solved := pending != nil && pending.challenge.Verify(strings.TrimSpace(message.Text))
+	if solved {
+		pending.timer.Stop()
+		delete(s.challenges.pending, userID)
+	}
+	s.challenges.mu.Unlock()
+
+	switch {
+	case pending == nil:
+		s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeBadSolution, "There is no challenge to solve."))
+	case !solved:
+		metrics.Challenges.WithLabelValues("rejected").Inc()
+		s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeBadSolution, "That does not solve the challenge."))
+	default:
+		metrics.Challenges.WithLabelValues("solved").Inc()
+		log.Printf("🧮 %s solved a difficulty %d challenge in %s", userID, pending.challenge.Difficulty, time.Since(pending.challenge.IssuedAt).Round(time.Millisecond))
+		if err := s.AddUserToQueue(ctx, pending.user); err != nil {
+			log.Printf("Error adding user to queue: %v", err)
+		}
+	}
+}
+
+// expireChallenge disconnects a user who did not solve their challenge in time
+func (s *ChatService) expireChallenge(userID string, pending *pendingChallenge) {
+	s.challenges.mu.Lock()
+	current := s.challenges.pending[userID] == pending
+	if current {
+		delete(s.challenges.pending, userID)
+	}
+	s.challenges.mu.Unlock()
+	if !current {
+		return // Solved or replaced meanwhile
+	}
+
+	metrics.Challenges.WithLabelValues("expired").Inc()
+	log.Printf("⌛ %s did not solve their challenge in %s", userID, s.pow.Timeout)
+	s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeChallengeExpired, "The challenge was not solved in time."))
+	s.wsRepo.RemoveConnection(userID)
+}
+
+// challengePending reports whether a user connected to this instance has yet to solve their challenge
+func (s *ChatService) challengePending(userID string) bool {
+	s.challenges.mu.Lock()
+	defer s.challenges.mu.Unlock()
+	return s.challenges.pending[userID] != nil
+}
+
+// forgetChallenge drops a user's unsolved challenge once their connection ends
+func (s *ChatService) forgetChallenge(userID string) {
+	s.challenges.mu.Lock()
+	defer s.challenges.mu.Unlock()
+	if pending := s.challenges.pending[userID]; pending != nil {
+		pending.timer.Stop()
+		delete(s.challenges.pending, userID)
+	}
+}
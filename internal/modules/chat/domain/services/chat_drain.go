@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+// drainPollInterval is how often Drain checks whether the read loops have exited
+const drainPollInterval = 100 * time.Millisecond
+
+// Drain ends every session owned by this instance before shutdown. Local users are
+// told to reconnect, their chats end with partners on other instances re-queued, and
+// only this instance's users are removed from the queue. It returns once every read
+// loop has exited or ctx is done.
+func (s *ChatService) Drain(ctx context.Context) error {
+	s.draining.Store(true)
+
+	local := s.listeningUsers()
+	log.Printf("🔻 Draining %d local users", len(local))
+
+	for _, userID := range local {
+		s.wsRepo.SendMessage(userID, entity.NewMessage(entity.MessageTypeServerRestarting, "🔁 Server restarting, please reconnect."))
+	}
+	for _, userID := range local {
+		if err := s.EndChatSession(ctx, userID, entity.EndReasonServerShutdown); err != nil {
+			log.Printf("⚠️ Error ending session for %s during drain: %v", userID, err)
+		}
+	}
+
+	// Dropped users waiting to resume here would never be cleaned up once we exit
+	for _, userID := range s.takeSuspended() {
+		if expired, err := s.sessionRepo.Expire(ctx, userID); err == nil && expired {
+			s.EndChatSession(ctx, userID, entity.EndReasonServerShutdown)
+		}
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for len(s.listeningUsers()) > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("⚠️ Drain deadline reached with %d read loops still running", len(s.listeningUsers()))
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	// Chats ended above are still being written to history
+	if err := s.waitForHistory(ctx); err != nil {
+		return err
+	}
+	if s.transcripts != nil {
+		if err := s.transcripts.close(ctx); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✅ Drain complete")
+	return nil
+}
+
+// drainingLocally reports whether the user belongs to this instance while it drains
+func (s *ChatService) drainingLocally(userID string) bool {
+	if !s.draining.Load() {
+		return false
+	}
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	_, local := s.listeners[userID]
+	return local
+}
+
+// listeningUsers returns the users with a read loop on this instance
+func (s *ChatService) listeningUsers() []string {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	userIDs := make([]string, 0, len(s.listeners))
+	for userID := range s.listeners {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// takeSuspended stops every pending resume expiry and returns the suspended users
+func (s *ChatService) takeSuspended() []string {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	userIDs := make([]string, 0, len(s.suspended))
+	for userID, timer := range s.suspended {
+		timer.Stop()
+		userIDs = append(userIDs, userID)
+	}
+	s.suspended = make(map[string]*time.Timer)
+	return userIDs
+}
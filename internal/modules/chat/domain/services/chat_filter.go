@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/filter"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// WithFilters runs every chat message through chain before it reaches the partner
+func WithFilters(chain filter.Chain) Option {
+	return func(s *ChatService) {
+		s.filters = chain
+	}
+}
+
+// filterMessage runs a chat message through the filters. A blocked message is answered
+// with an error naming the filter, and flags are filed with moderators. It returns the
+// text to deliver and whether to deliver it at all.
+func (s *ChatService) filterMessage(ctx context.Context, userID, text string) (string, bool) {
+	if len(s.filters) == 0 {
+		return text, true
+	}
+
+	outcome := s.filters.Run(text)
+	for _, name := range outcome.Rewritten {
+		metrics.MessagesFiltered.WithLabelValues(name, "rewrite").Inc()
+	}
+	for _, flag := range outcome.Flags {
+		metrics.MessagesFiltered.WithLabelValues(flag.Filter, "flag").Inc()
+		s.scoreAbuse(ctx, userID, abuseFilterFlag)
+	}
+	if len(outcome.Flags) > 0 {
+		s.flagMessage(ctx, userID, text, outcome.Flags)
+	}
+	if outcome.Blocked != nil {
+		metrics.MessagesFiltered.WithLabelValues(outcome.Blocked.Filter, "block").Inc()
+		s.scoreAbuse(ctx, userID, abuseFilterBlock)
+		reply := entity.NewErrorMessage(entity.ErrCodeMessageBlocked, outcome.Blocked.Reason)
+		reply.Reason = outcome.Blocked.Filter
+		s.wsRepo.SendMessage(userID, reply)
+		return "", false
+	}
+	return outcome.Text, true
+}
+
+// flagMessage files a report on a flagged message. The message and the chat's recent messages
+// are attached only when reports keep context, which is sealed when a transcript key is set.
+// A chat is flagged at most once; later flags in the same chat are only logged.
+func (s *ChatService) flagMessage(ctx context.Context, userID, text string, flags []filter.Finding) {
+	reasons := make([]string, len(flags))
+	for i, flag := range flags {
+		reasons[i] = fmt.Sprintf("%s: %s", flag.Filter, flag.Reason)
+	}
+	comment := "Flagged by " + strings.Join(reasons, "; ")
+
+	if s.reportRepo == nil {
+		log.Printf("🚩 %s in a message from %s; moderation is off, so no report was filed", comment, userID)
+		return
+	}
+	chat, err := s.currentChat(ctx, userID)
+	if err != nil {
+		return
+	}
+	partner := chatPartner(chat, userID)
+
+	report := entity.NewReport(chat.ID, entity.SystemReporterID, userID, entity.ReportReasonOther, comment)
+	if s.reportContext > 0 {
+		report.Messages = append(s.recentMessages(chat.ID, userID, partner.UserID), entity.NewTranscriptEntry(entity.NewChatMessage(userID, text)))
+	}
+	report.NodeID = s.nodeID
+
+	err = s.reportRepo.CreateReport(ctx, report)
+	switch {
+	case err == nil:
+		log.Printf("🚩 Flagged message from %s (report %s)", userID, report.ID)
+	case errors.Is(err, repository.ErrDuplicateReport):
+	default:
+		log.Printf("⚠️ Failed to file flag for %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/filter"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	web_socket_hub "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+)
+
+// recordingReports keeps filed reports in memory, one per chat and reporter like the real queue
+type recordingReports struct {
+	repository.ReportRepository
+	mu      sync.Mutex
+	reports []*entity.Report
+}
+
+func (r *recordingReports) CreateReport(ctx context.Context, report *entity.Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, filed := range r.reports {
+		if filed.ChatID == report.ChatID && filed.ReporterID == report.ReporterID {
+			return repository.ErrDuplicateReport
+		}
+	}
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+// newFilteredChat puts users a and b in a chat on a service running the test filter chain
+func newFilteredChat(t *testing.T, reports repository.ReportRepository, contextMessages int) (*ChatService, *fakeConnection) {
+	t.Helper()
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	chats := memory.NewChatRepository()
+	hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+	chain := filter.NewChain(
+		filter.WatchWords([]string{"secret"}),
+		filter.Profanity([]string{"darn"}),
+		filter.BlockLinks(),
+	)
+	s := NewChatService(chats, users, hub, WithFilters(chain), WithModeration(reports, nil, contextMessages))
+
+	chat := &entity.Chat{ID: "chat-1", UserA: entity.User{UserID: "a"}, UserB: entity.User{UserID: "b"}, StartTime: time.Now()}
+	for _, userID := range []string{"a", "b"} {
+		if err := users.UpdateUserChatID(ctx, userID, chat.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := chats.SaveChatSession(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+	conn := newFakeConnection()
+	hub.AddConnection("a", conn)
+	return s, conn
+}
+
+func TestFilterMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantText    string
+		wantDeliver bool
+		wantBlocked string // Filter named in the error sent back, if any
+		wantReports int
+	}{
+		{"clean", "hello", "hello", true, "", 0},
+		{"rewrite", "darn it", "**** it", true, "", 0},
+		{"block", "see example.com", "", false, "links", 0},
+		{"flag", "a secret", "a secret", true, "", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reports := &recordingReports{}
+			s, conn := newFilteredChat(t, reports, 0)
+
+			text, deliver := s.filterMessage(context.Background(), "a", test.text)
+			if text != test.wantText || deliver != test.wantDeliver {
+				t.Errorf("filterMessage = %q, %v; want %q, %v", text, deliver, test.wantText, test.wantDeliver)
+			}
+			if test.wantBlocked != "" {
+				reply := conn.expect(t, entity.MessageTypeError)
+				if reply.Code != entity.ErrCodeMessageBlocked || reply.Reason != test.wantBlocked {
+					t.Errorf("reply = %s/%s, want %s/%s", reply.Code, reply.Reason, entity.ErrCodeMessageBlocked, test.wantBlocked)
+				}
+			}
+			if len(reports.reports) != test.wantReports {
+				t.Errorf("filed %d reports, want %d", len(reports.reports), test.wantReports)
+			}
+		})
+	}
+}
+
+func TestFlaggedMessageFilesOneSystemReport(t *testing.T) {
+	tests := []struct {
+		name            string
+		contextMessages int
+		wantMessages    int
+	}{
+		{"without context the text is not kept", 0, 0},
+		{"with context the flagged message is attached", 20, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			reports := &recordingReports{}
+			s, _ := newFilteredChat(t, reports, test.contextMessages)
+
+			s.filterMessage(ctx, "a", "the secret plan")
+			s.filterMessage(ctx, "a", "another secret")
+
+			if len(reports.reports) != 1 {
+				t.Fatalf("filed %d reports, want one per chat", len(reports.reports))
+			}
+			report := reports.reports[0]
+			if report.ReporterID != entity.SystemReporterID || report.ReportedID != "a" || report.ChatID != "chat-1" {
+				t.Errorf("report = %+v, want a system report about a in chat-1", report)
+			}
+			if len(report.Messages) != test.wantMessages {
+				t.Errorf("report has %d messages, want %d", len(report.Messages), test.wantMessages)
+			}
+		})
+	}
+}
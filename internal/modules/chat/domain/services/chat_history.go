@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// historyWriteTimeout bounds a single chat history write
+const historyWriteTimeout = 5 * time.Second
+
+// WithChatHistory records every chat that ends on this instance
+func WithChatHistory(historyRepo repository.ChatHistoryRepository) Option {
+	return func(s *ChatService) {
+		s.historyRepo = historyRepo
+	}
+}
+
+// recordChatEnd stamps the chat's end time and writes its history in the background,
+// so a slow history store never holds up leaving or skipping
+func (s *ChatService) recordChatEnd(chat *entity.Chat, endedBy string, reason entity.EndReason) {
+	endTime := time.Now()
+	chat.EndTime = &endTime
+
+	if s.historyRepo == nil {
+		return
+	}
+	record := entity.NewChatRecord(chat, endedBy, reason, s.nodeID)
+
+	s.historyWG.Add(1)
+	go func() {
+		defer s.historyWG.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+		defer cancel()
+		if err := s.historyRepo.RecordChat(ctx, record); err != nil {
+			log.Printf("⚠️ Failed to record chat history: %v", err)
+		}
+	}()
+}
+
+// waitForHistory blocks until pending history writes finish or ctx is done
+func (s *ChatService) waitForHistory(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.historyWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Println("⚠️ Drain deadline reached with chat history writes pending")
+		return ctx.Err()
+	}
+}
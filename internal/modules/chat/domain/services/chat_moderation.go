@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// maxReportCommentRunes bounds the free-text comment attached to a report
+const maxReportCommentRunes = 500
+
+// Moderation errors returned by ChatService
+var (
+	ErrModerationDisabled = errors.New("moderation is not enabled")
+	ErrInvalidReason      = errors.New("invalid report reason")
+	ErrInvalidAction      = errors.New("invalid report action")
+	ErrInvalidBan         = errors.New("invalid ban")
+)
+
+// WithModeration lets users report partners into a moderation queue and lets moderators ban users, IP ranges and devices.
+// The last contextMessages messages relayed by this instance are attached to each report; 0 attaches none.
+func WithModeration(reportRepo repository.ReportRepository, banRepo repository.BanRepository, contextMessages int) Option {
+	return func(s *ChatService) {
+		s.reportRepo = reportRepo
+		s.banRepo = banRepo
+		s.reportContext = contextMessages
+	}
+}
+
+// FileReport queues a report about the reporter's current partner
+func (s *ChatService) FileReport(ctx context.Context, reporterID string, reason entity.ReportReason, comment string) (*entity.Report, error) {
+	if s.reportRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+	if !reason.Valid() {
+		return nil, ErrInvalidReason
+	}
+
+	chat, err := s.currentChat(ctx, reporterID)
+	if err != nil {
+		return nil, ErrNotInChat
+	}
+	partner := chatPartner(chat, reporterID)
+
+	if runes := []rune(comment); len(runes) > maxReportCommentRunes {
+		comment = string(runes[:maxReportCommentRunes])
+	}
+	report := entity.NewReport(chat.ID, reporterID, partner.UserID, reason, comment)
+	report.Messages = s.recentMessages(chat.ID, reporterID, partner.UserID)
+	report.NodeID = s.nodeID
+
+	if err := s.reportRepo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+	log.Printf("🚩 %s reported %s for %s (report %s)", reporterID, partner.UserID, reason, report.ID)
+	s.scoreAbuse(ctx, partner.UserID, abuseReportReceived)
+	return report, nil
+}
+
+// ListReports returns reports in the moderation queue
+func (s *ChatService) ListReports(ctx context.Context, status entity.ReportStatus, limit int) ([]entity.Report, error) {
+	if s.reportRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+	return s.reportRepo.ListReports(ctx, status, limit)
+}
+
+// GetReport retrieves a single report
+func (s *ChatService) GetReport(ctx context.Context, reportID string) (*entity.Report, error) {
+	if s.reportRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+	return s.reportRepo.GetReport(ctx, reportID)
+}
+
+// ResolveReport closes an open report on behalf of resolvedBy. Banning applies to the reported user
+// for banDuration; zero bans permanently.
+func (s *ChatService) ResolveReport(ctx context.Context, reportID string, action entity.ReportAction, note, resolvedBy string, banDuration time.Duration) (*entity.Report, error) {
+	if s.reportRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+	if action != entity.ReportActionDismiss && action != entity.ReportActionBan {
+		return nil, ErrInvalidAction
+	}
+
+	report, err := s.reportRepo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != entity.ReportStatusOpen {
+		return nil, repository.ErrReportResolved
+	}
+
+	resolution := entity.ReportResolution{Action: action, Note: note, ResolvedBy: resolvedBy, ResolvedAt: time.Now().UTC()}
+	if action == entity.ReportActionBan {
+		reason := fmt.Sprintf("report %s: %s", report.ID, report.Reason)
+		ban, err := s.CreateBan(ctx, entity.BanKindUser, entity.BanModeBlock, report.ReportedID, reason, resolvedBy, banDuration)
+		if err != nil {
+			return nil, err
+		}
+		resolution.BanID = ban.ID
+	}
+
+	resolved, err := s.reportRepo.ResolveReport(ctx, reportID, resolution)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("⚖️ Report %s resolved by %s: %s", reportID, resolvedBy, action)
+	return resolved, nil
+}
+
+// CreateBan bans a user, IP range or device for duration; zero bans permanently.
+// A blocked user loses their chat and queue place at once, wherever they are connected.
+// Clients matching an IP or device ban are refused when they next connect and are
+// skipped by matchmaking. Shadow bans take effect when their users next wait for a match.
+func (s *ChatService) CreateBan(ctx context.Context, kind entity.BanKind, mode entity.BanMode, value, reason, issuedBy string, duration time.Duration) (*entity.Ban, error) {
+	if s.banRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+
+	ban, err := entity.NewBan(kind, mode, value, reason, issuedBy, duration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBan, err)
+	}
+	if err := s.banRepo.CreateBan(ctx, ban); err != nil {
+		return nil, err
+	}
+	log.Printf("🔨 %s banned %s %s (%s, %s)", issuedBy, ban.Kind, ban.Value, ban.Mode, reason)
+
+	if kind == entity.BanKindUser && !ban.Shadow() && s.IsUserActive(ctx, ban.Value) {
+		s.evictBanned(ctx, ban.Value)
+	}
+	return ban, nil
+}
+
+// ListBans returns active bans, newest first
+func (s *ChatService) ListBans(ctx context.Context, limit int) ([]entity.Ban, error) {
+	if s.banRepo == nil {
+		return nil, ErrModerationDisabled
+	}
+	return s.banRepo.ListBans(ctx, limit)
+}
+
+// LiftBan removes a ban before it expires
+func (s *ChatService) LiftBan(ctx context.Context, banID, liftedBy string) error {
+	if s.banRepo == nil {
+		return ErrModerationDisabled
+	}
+	if err := s.banRepo.DeleteBan(ctx, banID); err != nil {
+		return err
+	}
+	log.Printf("🕊️ %s lifted ban %s", liftedBy, banID)
+	return nil
+}
+
+// FindBan returns a ban on the user, their IP or their device, or nil. A blocking ban wins
+// over a shadow one. An empty userID or client field is not checked. Bans fail open if
+// they cannot be read.
+func (s *ChatService) FindBan(ctx context.Context, userID string, client entity.ClientInfo) *entity.Ban {
+	if s.banRepo == nil {
+		return nil
+	}
+
+	checks := []struct {
+		kind  entity.BanKind
+		value string
+	}{
+		{entity.BanKindUser, userID},
+		{entity.BanKindDevice, client.DeviceID},
+		{entity.BanKindIP, client.IP},
+	}
+	var shadow *entity.Ban
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+		ban, err := s.banRepo.FindBan(ctx, check.kind, check.value)
+		if err != nil {
+			log.Printf("⚠️ Failed to check %s ban for %s: %v", check.kind, check.value, err)
+			continue
+		}
+		if ban != nil && !ban.Shadow() {
+			return ban
+		}
+		if shadow == nil {
+			shadow = ban
+		}
+	}
+	return shadow
+}
+
+// ScreenQueuedUser checks a waiting user's bans before they are matched. A blocked user
+// is taken out of the queue and their session ended; a user whose shadow ban began or
+// ended while they waited moves to the right matchmaking pool. It reports whether the
+// user may be matched as they are.
+func (s *ChatService) ScreenQueuedUser(ctx context.Context, user entity.User) (bool, error) {
+	ban := s.FindBan(ctx, user.UserID, user.Client)
+	if ban != nil && !ban.Shadow() {
+		log.Printf("🔨 Skipping banned user %s (%s ban %s)", user.UserID, ban.Kind, ban.ID)
+		s.evictBanned(ctx, user.UserID)
+		return false, nil
+	}
+
+	// Shadow-banned users are not told; they simply only meet each other
+	if shadow := ban != nil; shadow != user.Shadow {
+		if err := s.userRepo.SetShadow(ctx, user.UserID, shadow); err != nil {
+			return false, err
+		}
+		log.Printf("👻 Moved %s to the %s pool", user.UserID, poolName(shadow))
+		return false, nil
+	}
+	return true, nil
+}
+
+// poolName names a matchmaking pool for logs
+func poolName(shadow bool) string {
+	if shadow {
+		return "shadow"
+	}
+	return "regular"
+}
+
+// evictBanned tells a banned user and ends their session
+func (s *ChatService) evictBanned(ctx context.Context, userID string) {
+	if err := s.userRepo.RemoveFromQueue(ctx, userID); err != nil {
+		log.Printf("⚠️ Failed to remove banned user %s from queue: %v", userID, err)
+	}
+	s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeBanned, "You have been banned."))
+	s.EndChatSession(ctx, userID, entity.EndReasonBanned)
+}
+
+// handleReport files a report command and tells the reporter the outcome
+func (s *ChatService) handleReport(ctx context.Context, userID string, message *entity.Message) {
+	_, err := s.FileReport(ctx, userID, entity.ReportReason(message.Reason), message.Text)
+	switch {
+	case err == nil:
+		s.wsRepo.SendMessage(userID, entity.NewMessage(entity.MessageTypeSystem, "🚩 Report received. Thank you."))
+	case errors.Is(err, ErrInvalidReason):
+		s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeInvalidReason, fmt.Sprintf("Unknown report reason %q.", message.Reason)))
+	case errors.Is(err, ErrNotInChat):
+		s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeNotInChat, "You are not in a chat."))
+	case errors.Is(err, repository.ErrDuplicateReport):
+		s.wsRepo.SendMessage(userID, entity.NewMessage(entity.MessageTypeSystem, "🚩 You already reported this chat."))
+	default:
+		log.Printf("⚠️ Failed to file report from %s: %v", userID, err)
+		s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeReportFailed, "Your report could not be filed."))
+	}
+}
+
+// recentEntry is a relayed message remembered for report context
+type recentEntry struct {
+	chatID string
+	entry  entity.TranscriptEntry
+}
+
+// rememberMessage keeps the sender's last messages for report context
+func (s *ChatService) rememberMessage(userID, chatID string, message *entity.Message) {
+	if s.reportContext <= 0 {
+		return
+	}
+
+	s.recentMu.Lock()
+	defer s.recentMu.Unlock()
+
+	recent := append(s.recent[userID], recentEntry{chatID: chatID, entry: entity.NewTranscriptEntry(message)})
+	if len(recent) > s.reportContext {
+		recent = recent[len(recent)-s.reportContext:]
+	}
+	s.recent[userID] = recent
+}
+
+// forgetMessages drops a user's remembered messages once their connection ends
+func (s *ChatService) forgetMessages(userID string) {
+	s.recentMu.Lock()
+	defer s.recentMu.Unlock()
+	delete(s.recent, userID)
+}
+
+// recentMessages returns the last remembered messages of a chat from both users, oldest first.
+// Messages from a partner connected to another instance are not remembered here.
+func (s *ChatService) recentMessages(chatID string, userIDs ...string) []entity.TranscriptEntry {
+	if s.reportContext <= 0 {
+		return nil
+	}
+
+	s.recentMu.Lock()
+	var entries []entity.TranscriptEntry
+	for _, userID := range userIDs {
+		for _, recent := range s.recent[userID] {
+			if recent.chatID == chatID {
+				entries = append(entries, recent.entry)
+			}
+		}
+	}
+	s.recentMu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	if len(entries) > s.reportContext {
+		entries = entries[len(entries)-s.reportContext:]
+	}
+	return entries
+}
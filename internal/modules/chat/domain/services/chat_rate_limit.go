@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// RateLimits bounds how fast clients may connect and send; a zero value disables a limit
+type RateLimits struct {
+	ConnectionsPerMinute int // Connection attempts per client IP
+	MessagesPerSecond    int // Messages per user
+	BytesPerMinute       int // Message text bytes per user
+	MaxViolations        int // Rejected messages per user per minute before disconnecting
+}
+
+// rateVerdict is the outcome of checking a message against the rate limits
+type rateVerdict int
+
+const (
+	rateAllowed    rateVerdict = iota // Forward the message
+	rateLimited                       // Drop the message and warn the sender
+	rateDisconnect                    // The sender keeps violating the limits
+)
+
+// WithRateLimits enforces connection and message limits shared across instances through limiter
+func WithRateLimits(limiter repository.RateLimitRepository, limits RateLimits) Option {
+	return func(s *ChatService) {
+		s.rateLimiter = limiter
+		s.rateLimits = limits
+	}
+}
+
+// AllowConnection reports whether the client IP may open another connection.
+// Limiter failures let the connection through rather than lock everyone out.
+func (s *ChatService) AllowConnection(ctx context.Context, clientIP string) bool {
+	if s.rateLimiter == nil || s.rateLimits.ConnectionsPerMinute <= 0 {
+		return true
+	}
+	allowed, err := s.rateLimiter.Allow(ctx, "conn:"+clientIP, int64(s.rateLimits.ConnectionsPerMinute), time.Minute, 1)
+	if err != nil {
+		return true
+	}
+	if !allowed {
+		log.Printf("🚦 Connection rate limit hit for %s", clientIP)
+	}
+	return allowed
+}
+
+// checkMessageRate counts a message against the sender's message and byte limits
+func (s *ChatService) checkMessageRate(ctx context.Context, userID string, message *entity.Message) rateVerdict {
+	if s.rateLimiter == nil {
+		return rateAllowed
+	}
+
+	if s.rateLimits.MessagesPerSecond > 0 && !s.allow(ctx, "msg:"+userID, s.rateLimits.MessagesPerSecond, time.Second, 1) {
+		return s.recordViolation(ctx, userID)
+	}
+	if size := len(message.Text); size > 0 && s.rateLimits.BytesPerMinute > 0 &&
+		!s.allow(ctx, "bytes:"+userID, s.rateLimits.BytesPerMinute, time.Minute, size) {
+		return s.recordViolation(ctx, userID)
+	}
+	if message.Type == entity.MessageTypeChat && s.throttled(ctx, userID) &&
+		!s.allow(ctx, "throttle:"+userID, s.abuse.ThrottleRate, time.Minute, 1) {
+		return rateLimited // Throttling is already the response to abuse, so it is not scored again
+	}
+	return rateAllowed
+}
+
+// recordViolation counts a rejected message and decides whether the sender is a repeat violator
+func (s *ChatService) recordViolation(ctx context.Context, userID string) rateVerdict {
+	s.scoreAbuse(ctx, userID, abuseRateViolation)
+	if s.rateLimits.MaxViolations > 0 && !s.allow(ctx, "violations:"+userID, s.rateLimits.MaxViolations, time.Minute, 1) {
+		log.Printf("🚦 Disconnecting %s for repeated rate limit violations", userID)
+		return rateDisconnect
+	}
+	return rateLimited
+}
+
+// allow checks one limit, failing open if the limiter is unavailable
+func (s *ChatService) allow(ctx context.Context, key string, limit int, window time.Duration, cost int) bool {
+	allowed, err := s.rateLimiter.Allow(ctx, key, int64(limit), window, int64(cost))
+	return err != nil || allowed
+}
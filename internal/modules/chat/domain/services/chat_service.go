@@ -2,85 +2,339 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/royroki/LetsGo/internal/common/metrics"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/filter"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 )
 
+// Domain errors returned by ChatService
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrNotInChat    = errors.New("user is not in a chat")
+	ErrNoSession    = errors.New("session cannot be resumed")
+)
+
+// defaultSkipCooldown keeps a skipped pair apart when no cooldown is configured
+const defaultSkipCooldown = time.Minute
+
 // ChatService handles domain logic for chat
 type ChatService struct {
 	chatRepo repository.ChatRepository
 	userRepo repository.UserRepository
 	wsRepo   repository.WebSocketRepository
+
+	skipCooldown time.Duration // How long a skipped pair is kept from matching again
+
+	sessionRepo repository.SessionRepository // nil disables session resume
+	resumeGrace time.Duration                // How long a dropped user may take to reconnect
+
+	rateLimiter repository.RateLimitRepository // nil disables rate limiting
+	rateLimits  RateLimits
+
+	nodeID string // This instance, recorded in chat history and reports
+
+	historyRepo repository.ChatHistoryRepository // nil disables chat history
+	historyWG   sync.WaitGroup                   // Pending history writes
+
+	transcripts *transcriptRecorder // nil disables transcripts
+
+	filters filter.Chain // Content filters applied to chat messages, in order
+
+	abuseRepo repository.AbuseScoreRepository // nil disables abuse scoring
+	abuse     AbuseScoring
+	repeats   repeatTracker
+
+	pow        ProofOfWork // Zero difficulty queues users without a challenge
+	challenges challengeSet
+
+	reportRepo    repository.ReportRepository // nil disables reports
+	banRepo       repository.BanRepository    // nil disables bans
+	reportContext int                         // Recent messages attached to each report
+	recentMu      sync.Mutex
+	recent        map[string][]recentEntry // Last messages sent by each local user, oldest first
+
+	listenersMu sync.Mutex
+	listeners   map[string]struct{}    // Users with an active read loop
+	suspended   map[string]*time.Timer // Pending resume expiries owned by this instance
+
+	draining atomic.Bool // Set once Drain starts
+}
+
+// Option customises a ChatService
+type Option func(*ChatService)
+
+// WithSkipCooldown sets how long two users who skipped each other stay apart
+func WithSkipCooldown(cooldown time.Duration) Option {
+	return func(s *ChatService) {
+		s.skipCooldown = cooldown
+	}
+}
+
+// WithNodeID names this instance in the records it writes
+func WithNodeID(nodeID string) Option {
+	return func(s *ChatService) {
+		s.nodeID = nodeID
+	}
+}
+
+// WithSessionResume lets users whose connection drops resume their session within grace
+func WithSessionResume(sessionRepo repository.SessionRepository, grace time.Duration) Option {
+	return func(s *ChatService) {
+		if grace > 0 {
+			s.sessionRepo = sessionRepo
+			s.resumeGrace = grace
+		}
+	}
 }
 
 // NewChatService initializes ChatService
-func NewChatService(chatRepo repository.ChatRepository, userRepo repository.UserRepository, wsRepo repository.WebSocketRepository) *ChatService {
-	return &ChatService{chatRepo: chatRepo, userRepo: userRepo, wsRepo: wsRepo}
+func NewChatService(chatRepo repository.ChatRepository, userRepo repository.UserRepository, wsRepo repository.WebSocketRepository, opts ...Option) *ChatService {
+	s := &ChatService{
+		chatRepo:     chatRepo,
+		userRepo:     userRepo,
+		wsRepo:       wsRepo,
+		skipCooldown: defaultSkipCooldown,
+		listeners:    make(map[string]struct{}),
+		suspended:    make(map[string]*time.Timer),
+		recent:       make(map[string][]recentEntry),
+		repeats:      repeatTracker{texts: make(map[string]map[uint64]string)},
+		challenges:   challengeSet{pending: make(map[string]*pendingChallenge)},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetChatPartner retrieves the chat partner of a user
 func (s *ChatService) GetChatPartner(ctx context.Context, userID string) (*entity.User, error) {
+	chat, err := s.currentChat(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	partner := chatPartner(chat, userID)
+	return &partner, nil
+}
+
+// currentChat retrieves the chat the user is in
+func (s *ChatService) currentChat(ctx context.Context, userID string) (*entity.Chat, error) {
 	user, err := s.userRepo.GetUser(ctx, userID)
 	if err != nil {
 		log.Printf("Error retrieving user for user id %s\n", userID)
 		return nil, err
 	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.ChatID == "" {
+		return nil, ErrNotInChat
+	}
 
 	chat, err := s.chatRepo.GetChatSession(ctx, user.ChatID)
 	if err != nil {
 		log.Printf("Error retrieving chat session for user %s: %v", userID, err)
 		return nil, err
 	}
+	return chat, nil
+}
 
-	if chat.UserA.UserID == userID {
-		return &chat.UserB, nil
+// EndChatSession removes a disconnected user, ending their chat and re-adding the partner to the queue
+func (s *ChatService) EndChatSession(ctx context.Context, userID string, reason entity.EndReason) error {
+	defer s.wsRepo.RemoveConnection(userID)
+
+	user, err := s.userRepo.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		log.Printf("Error retrieving user %s for delete: %v", userID, err)
+		return err
+	}
+
+	if user.ChatID != "" {
+		chat, err := s.chatRepo.GetChatSession(ctx, user.ChatID)
+		if err != nil {
+			// Already ended by the partner; the user still has to be removed
+			log.Printf("Error retrieving chat session for delete: %v", err)
+			return s.userRepo.RemoveUser(ctx, userID)
+		}
+
+		ended, err := s.chatRepo.DeleteChatSession(ctx, chat.ID)
+		if err != nil {
+			log.Printf("Error deleting chat session: %v", err)
+			return err
+		}
+
+		// A partner who left or skipped at the same moment already ended the chat
+		if ended {
+			// Send message for disconnect
+			partner := chatPartner(chat, userID)
+			s.wsRepo.SendMessage(partner.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerLeft, userID, partnerLeftText(reason)))
+			if !s.drainingLocally(partner.UserID) { // A partner on this instance is leaving too
+				err = s.wsRepo.SendMessage(partner.UserID, entity.NewMessage(entity.MessageTypeQueued, "Wait for new partner..."))
+				if err == nil {
+					s.requeue(ctx, partner)
+				}
+			}
+			s.recordChatEnd(chat, userID, reason)
+		}
+	}
+
+	if err := s.userRepo.RemoveUser(ctx, userID); err != nil {
+		log.Printf("Error removing user %s: %v", userID, err)
+		return err
+	}
+
+	log.Printf("User %s removed (%s)", userID, reason)
+	return nil
+}
+
+// partnerLeftText tells the remaining user why their partner is gone
+func partnerLeftText(reason entity.EndReason) string {
+	switch reason {
+	case entity.EndReasonConnectionLost:
+		return "⚠️ Partner lost: their connection stopped responding."
+	case entity.EndReasonSkipped:
+		return "Your partner skipped."
+	case entity.EndReasonServerShutdown:
+		return "Your partner's server is restarting."
+	default:
+		return "Your partner is disconnected."
 	}
-	return &chat.UserA, nil
 }
 
-// EndChatSession removes a chat session and re-adds users to the queue
-func (s *ChatService) EndChatSession(ctx context.Context, userID string) error {
+// IsUserActive reports whether the user still has a session, connected or suspended.
+// A user still solving their challenge has no record yet but is connected all the same.
+func (s *ChatService) IsUserActive(ctx context.Context, userID string) bool {
+	if s.challengePending(userID) {
+		return true
+	}
 	user, err := s.userRepo.GetUser(ctx, userID)
+	return err == nil && user != nil
+}
+
+// SkipPartner ends the user's current chat and puts both users back in the queue.
+// Connections stay open and the pair is kept apart for the skip cooldown.
+func (s *ChatService) SkipPartner(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.ChatID == "" {
+		return ErrNotInChat
+	}
+
 	chat, err := s.chatRepo.GetChatSession(ctx, user.ChatID)
 	if err != nil {
-		log.Printf("Error retrieving chat session for delete: %v", err)
+		// The partner skipped or left at the same moment
+		return ErrNotInChat
+	}
+	partner := chatPartner(chat, userID)
+	self := chatPartner(chat, partner.UserID)
+
+	ended, err := s.chatRepo.DeleteChatSession(ctx, chat.ID)
+	if err != nil {
+		log.Printf("Error deleting chat session: %v", err)
 		return err
 	}
+	if !ended {
+		// The partner skipped or left at the same moment and re-queued us both
+		return ErrNotInChat
+	}
+	s.recordChatEnd(chat, userID, entity.EndReasonSkipped)
+	s.scoreShortChat(ctx, chat, partner.UserID)
 
-	// Send message for disconnect
-	if chat.UserA.UserID != userID {
-		err = s.wsRepo.SendMessage(chat.UserA.UserID, []byte("Wait for new partner..."))
-		if err == nil {
-			s.userRepo.AddUserToQueue(ctx, chat.UserA)
-		}
-	} else {
-		err = s.wsRepo.SendMessage(chat.UserB.UserID, []byte("Wait for new partner..."))
-		if err == nil {
-			s.userRepo.AddUserToQueue(ctx, chat.UserB)
+	if err := s.userRepo.MarkSkipped(ctx, userID, partner.UserID, s.skipCooldown); err != nil {
+		log.Printf("⚠️ Failed to record skip of %s by %s: %v", partner.UserID, userID, err)
+	}
+
+	s.wsRepo.SendMessage(partner.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerLeft, userID, partnerLeftText(entity.EndReasonSkipped)+" Wait for new partner..."))
+	s.wsRepo.SendMessage(userID, entity.NewMessage(entity.MessageTypeQueued, "Looking for a new partner..."))
+
+	s.requeue(ctx, partner)
+	s.requeue(ctx, self)
+
+	log.Printf("⏭️ User %s skipped %s (ChatID: %s)", userID, partner.UserID, chat.ID)
+	return nil
+}
+
+// requeue puts a user whose chat ended back in the waiting queue
+func (s *ChatService) requeue(ctx context.Context, user entity.User) {
+	user.ChatID = ""
+	if err := s.userRepo.AddUserToQueue(ctx, user); err != nil {
+		log.Printf("❌ Error re-queuing user %s: %v", user.UserID, err)
+	}
+}
+
+// ReturnToQueue re-queues claimed users whose chat could not be created, clearing any
+// chat ID already written for them. Users who left in the meantime stay gone.
+func (s *ChatService) ReturnToQueue(ctx context.Context, userIDs ...string) {
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetUser(ctx, userID)
+		if err != nil || user == nil {
+			log.Printf("⚠️ Not re-queuing user %s: %v", userID, err)
+			continue
 		}
+		s.requeue(ctx, *user)
+	}
+}
+
+// ReleaseClaim clears the claim record of a pair once their chat was created or abandoned
+func (s *ChatService) ReleaseClaim(ctx context.Context, userIDs ...string) {
+	if err := s.userRepo.ReleaseClaim(ctx, userIDs...); err != nil {
+		log.Printf("⚠️ Claim on users %v left to expire: %v", userIDs, err)
 	}
+}
 
-	err = s.chatRepo.DeleteChatSession(ctx, chat.ID)
+// RecoverStaleClaims re-queues users whose claim expired before they were released, which
+// means the worker pairing them stopped midway. Users who left or reached their chat stay put.
+func (s *ChatService) RecoverStaleClaims(ctx context.Context) error {
+	claims, err := s.userRepo.TakeExpiredClaims(ctx, time.Now())
 	if err != nil {
-		log.Printf("Error deleting chat session: %v", err)
 		return err
 	}
-	s.wsRepo.RemoveConnection(userID)
-	s.userRepo.RemoveUser(ctx, userID)
 
-	log.Printf("Chat session")
+	for _, userIDs := range claims {
+		for _, userID := range userIDs {
+			user, err := s.userRepo.GetUser(ctx, userID)
+			if err != nil || user == nil {
+				continue
+			}
+			if user.ChatID != "" {
+				if _, err := s.chatRepo.GetChatSession(ctx, user.ChatID); err == nil {
+					continue // The chat was created after all
+				}
+			}
+			log.Printf("♻️ Re-queuing user %s from a stale claim", userID)
+			s.requeue(ctx, *user)
+		}
+	}
 	return nil
 }
 
+// chatPartner returns the participant of chat that is not userID
+func chatPartner(chat *entity.Chat, userID string) entity.User {
+	if chat.UserA.UserID == userID {
+		return chat.UserB
+	}
+	return chat.UserA
+}
+
 // AddUserToQueue calls UserRepository method via ChatService
 func (s *ChatService) AddUserToQueue(ctx context.Context, user entity.User) error {
 	err := s.userRepo.AddUserToQueue(ctx, user)
 	if err == nil {
 		message := fmt.Sprintf("ID : %s \nLetsGo wait for partner....", user.UserID)
-		s.wsRepo.SendMessage(user.UserID, []byte(message))
+		s.wsRepo.SendMessage(user.UserID, entity.NewMessage(entity.MessageTypeQueued, message))
 	}
 	return err
 }
@@ -97,77 +351,254 @@ func (s *ChatService) CreateChatSession(ctx context.Context, chat *entity.Chat)
 	s.chatRepo.NotifyPartnerUpdate(ctx, chat.UserB.UserID, &chat.UserA)
 
 	// Send message for new chat
-	s.wsRepo.SendMessage(chat.UserA.UserID, []byte("💬 Open new chat with "+string(chat.UserB.UserID)))
-	s.wsRepo.SendMessage(chat.UserB.UserID, []byte("💬 Open new chat with "+string(chat.UserA.UserID)))
+	s.wsRepo.SendMessage(chat.UserA.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerJoined, chat.UserB.UserID, "💬 Open new chat with "+chat.UserB.UserID))
+	s.wsRepo.SendMessage(chat.UserB.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerJoined, chat.UserA.UserID, "💬 Open new chat with "+chat.UserA.UserID))
 
 	return nil
 }
 
-// ListenFromConnection listens for messages from a connected user
+// ListenFromConnection listens for messages from a connected user.
+// There is one read loop per connection; it outlives individual chats.
 func (s *ChatService) ListenFromConnection(userID string) {
-	ctx := context.Background()
+	if !s.startListening(userID) {
+		return // Already listening, e.g. the user was re-matched
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	ws := s.wsRepo.GetConnection(userID)
 	if ws == nil {
 		log.Printf("⚠️ No active WebSocket connection for user %s", userID)
+		s.stopListening(userID)
 		return
 	}
 
-	user, err := s.userRepo.GetUser(ctx, userID)
-	if err != nil {
-		log.Println("Failed to listen.")
-	}
-
 	partnerUpdates := s.chatRepo.SubscribeToChatUpdates(ctx, userID)
-	partner, err := s.chatRepo.GetChatPartner(ctx, user.ChatID, user.UserID)
-	if err != nil {
-		log.Println("⚠️ Failed to fetch initial chat partner")
-		return
-	}
 
 	// Goroutine to handle partner updates
 	go func() {
-		for newPartner := range partnerUpdates {
-			partner = newPartner // Update partner dynamically
+		for range partnerUpdates {
 			message := "🔄 Your chat partner has changed."
-			s.wsRepo.SendMessage(userID, []byte(message))
+			s.wsRepo.SendMessage(userID, entity.NewMessage(entity.MessageTypeSystem, message))
 		}
 	}()
 
+	reason := entity.EndReasonDisconnected
+	resumable := s.sessionRepo != nil
 	defer func() {
-		message := "Your partner is disconnected."
-		err := s.wsRepo.SendMessage(partner.UserID, []byte(message))
+		// Free the listener slot first so a quick resume can start a new read loop
+		s.stopListening(userID)
+		s.forgetMessages(userID)
+		s.forgetRepeats(userID)
+		s.forgetChallenge(userID)
+		ws.Close()
 
-		if err != nil {
-			log.Println("Failed to send disconnec message.")
+		// A dropped connection may come back; a deliberate close or a drain ends the session now
+		if resumable && !s.draining.Load() {
+			s.suspendSession(userID, reason)
+		} else {
+			s.EndChatSession(context.Background(), userID, reason)
 		}
-		// When user disconnects, remove from WebSocket hub and queue
-		ws.Close()
-		s.EndChatSession(context.Background(), userID)
 		log.Printf("User disconnected: %s", userID)
 	}()
 
 	for {
 		// Read incoming message
-		_, message, err := ws.ReadMessage()
+		message, err := s.wsRepo.ReadMessage(userID)
+		if errors.Is(err, repository.ErrInvalidMessage) {
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeBadRequest, "Malformed message."))
+			continue
+		}
+		if errors.Is(err, repository.ErrClosedByClient) {
+			resumable = false
+			break
+		}
+		if errors.Is(err, repository.ErrConnectionLost) {
+			reason = entity.EndReasonConnectionLost
+			log.Printf("💔 Heartbeat failed for %s: %v", userID, err)
+			break
+		}
 		if err != nil {
-			s.wsRepo.SendMessage(partner.UserID, []byte("Partner Connection failed!"))
 			log.Printf("⚠️ Error reading message from %s: %v", userID, err)
 			break // Exit loop on error (disconnect)
 		}
 
-		log.Printf("📩 Received message from %s: %s", userID, string(message))
+		verdict := s.checkMessageRate(ctx, userID, message)
+		if verdict == rateDisconnect {
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeRateLimited, "Disconnected for sending too fast."))
+			reason = entity.EndReasonRateLimited
+			resumable = false
+			break
+		}
+		if verdict == rateLimited {
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeRateLimited, "Slow down, you are sending too fast."))
+			continue
+		}
+
+		var outgoing *entity.Message
+		switch message.Type {
+		case entity.MessageTypeChat:
+			text, deliver := s.filterMessage(ctx, userID, message.Text)
+			if !deliver {
+				continue
+			}
+			outgoing = entity.NewChatMessage(userID, text)
+		case entity.MessageTypeTyping:
+			outgoing = entity.NewMessage(entity.MessageTypeTyping, "")
+			outgoing.From = userID
+		case entity.MessageTypeSkip:
+			if err := s.SkipPartner(ctx, userID); err != nil {
+				s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeNotInChat, "You are not in a chat."))
+			}
+			continue
+		case entity.MessageTypeReport:
+			s.handleReport(ctx, userID, message)
+			continue
+		case entity.MessageTypeSolution:
+			s.handleSolution(ctx, userID, message)
+			continue
+		default:
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeUnsupportedType, fmt.Sprintf("Unsupported message type %q.", message.Type)))
+			continue
+		}
+
+		// Look up the chat per message: chats end and start under a live connection
+		chat, err := s.currentChat(ctx, userID)
+		if err != nil {
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeNotInChat, "You are not in a chat."))
+			continue
+		}
+		partner := chatPartner(chat, userID)
 
 		// Forward the message to the user's chat partner
-		err = s.wsRepo.SendMessage(partner.UserID, message)
+		err = s.wsRepo.SendMessage(partner.UserID, outgoing)
 		if err != nil {
-			s.wsRepo.SendMessage(userID, []byte("Server Failed!"))
+			s.wsRepo.SendMessage(userID, entity.NewErrorMessage(entity.ErrCodeForwardFailed, "Server Failed!"))
 			log.Printf("⚠️ Error forwarding message: %v", err)
-			break
+			continue
+		}
+		if outgoing.Type == entity.MessageTypeChat {
+			if err := s.chatRepo.IncrementMessageCount(ctx, chat.ID, userID); err != nil {
+				log.Printf("⚠️ Failed to count message in chat %s: %v", chat.ID, err)
+			}
+			if s.transcripts != nil {
+				s.transcripts.record(chat.ID, outgoing)
+			}
+			s.rememberMessage(userID, chat.ID, outgoing)
+			s.scoreRepeatedText(ctx, userID, chat.ID, outgoing.Text)
+			metrics.MessagesForwarded.Inc()
+			metrics.BytesRelayed.Add(float64(len(outgoing.Text)))
 		}
 	}
 }
 
+// suspendSession keeps a dropped user's identity and chat for the resume grace period.
+// The partner is told the user is reconnecting; if nobody resumes in time the chat ends.
+func (s *ChatService) suspendSession(userID string, reason entity.EndReason) {
+	ctx := context.Background()
+	s.wsRepo.RemoveConnection(userID)
+
+	partner, err := s.GetChatPartner(ctx, userID)
+	switch {
+	case err == nil:
+		s.wsRepo.SendMessage(partner.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerReconnecting, userID, "⏳ Partner reconnecting..."))
+	case errors.Is(err, ErrUserNotFound):
+		return // Nothing left to resume
+	default:
+		// Keep a queued user out of matchmaking while they are away
+		s.userRepo.RemoveFromQueue(ctx, userID)
+	}
+
+	if err := s.sessionRepo.Suspend(ctx, userID, s.resumeGrace); err != nil {
+		s.EndChatSession(ctx, userID, reason)
+		return
+	}
+	log.Printf("⏳ Session for %s suspended for %s", userID, s.resumeGrace)
+
+	timer := time.AfterFunc(s.resumeGrace, func() {
+		s.forgetSuspended(userID)
+		expired, err := s.sessionRepo.Expire(context.Background(), userID)
+		if err != nil || !expired {
+			return // Resumed, possibly on another instance
+		}
+		s.EndChatSession(context.Background(), userID, reason)
+	})
+
+	s.listenersMu.Lock()
+	if previous, exists := s.suspended[userID]; exists {
+		previous.Stop()
+	}
+	s.suspended[userID] = timer
+	s.listenersMu.Unlock()
+}
+
+// forgetSuspended drops the user's pending resume expiry
+func (s *ChatService) forgetSuspended(userID string) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	delete(s.suspended, userID)
+}
+
+// ResumeSession claims a suspended session so the user can reattach to it
+func (s *ChatService) ResumeSession(ctx context.Context, userID string) error {
+	if s.sessionRepo == nil {
+		return ErrNoSession
+	}
+	resumed, err := s.sessionRepo.Resume(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !resumed {
+		return ErrNoSession
+	}
+	return nil
+}
+
+// HandleResumedConnection reattaches a resumed user to their chat, or re-queues
+// them if the chat ended while they were away
+func (s *ChatService) HandleResumedConnection(ctx context.Context, userID string) error {
+	partner, err := s.GetChatPartner(ctx, userID)
+	if err == nil {
+		s.wsRepo.SendMessage(partner.UserID, entity.NewPartnerMessage(entity.MessageTypePartnerReconnected, userID, "✅ Partner reconnected."))
+		s.wsRepo.SendMessage(userID, entity.NewPartnerMessage(entity.MessageTypeSystem, partner.UserID, "🔄 Resumed chat with "+partner.UserID))
+		log.Printf("🔄 User %s resumed chat with %s", userID, partner.UserID)
+		return nil
+	}
+	if errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	user, err := s.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	user.ChatID = ""
+	return s.AddUserToQueue(ctx, *user)
+}
+
+// startListening registers a read loop for the user, reporting false if one is already running
+func (s *ChatService) startListening(userID string) bool {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	if _, exists := s.listeners[userID]; exists {
+		return false
+	}
+	s.listeners[userID] = struct{}{}
+	return true
+}
+
+// stopListening unregisters the user's read loop
+func (s *ChatService) stopListening(userID string) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	delete(s.listeners, userID)
+}
+
 // UpdateUserChatID updates the user's chat ID
 func (s *ChatService) UpdateUserChatID(ctx context.Context, userID, chatID string) error {
 	return s.userRepo.UpdateUserChatID(ctx, userID, chatID)
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	web_socket_hub "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+)
+
+// racingChats holds the first two chat reads until both have happened, so both
+// users act on the same chat as if they pressed at the same moment
+type racingChats struct {
+	repository.ChatRepository
+	arrived sync.WaitGroup
+	reads   int
+	mu      sync.Mutex
+}
+
+func newRacingChats(chats repository.ChatRepository) *racingChats {
+	r := &racingChats{ChatRepository: chats}
+	r.arrived.Add(2)
+	return r
+}
+
+func (r *racingChats) GetChatSession(ctx context.Context, chatID string) (*entity.Chat, error) {
+	chat, err := r.ChatRepository.GetChatSession(ctx, chatID)
+
+	r.mu.Lock()
+	read := r.reads
+	r.reads++
+	r.mu.Unlock()
+	if read < 2 {
+		r.arrived.Done()
+		r.arrived.Wait()
+	}
+	return chat, err
+}
+
+// countingQueue counts how often each user is put in the queue
+type countingQueue struct {
+	repository.UserRepository
+	mu     sync.Mutex
+	queued map[string]int
+}
+
+func (r *countingQueue) AddUserToQueue(ctx context.Context, user entity.User) error {
+	r.mu.Lock()
+	r.queued[user.UserID]++
+	r.mu.Unlock()
+	return r.UserRepository.AddUserToQueue(ctx, user)
+}
+
+// countingHistory counts recorded chats
+type countingHistory struct {
+	mu      sync.Mutex
+	records int
+}
+
+func (h *countingHistory) RecordChat(ctx context.Context, record entity.ChatRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records++
+	return nil
+}
+
+func TestRacingUsersEndAChatOnce(t *testing.T) {
+	skip := func(s *ChatService, userID string) { s.SkipPartner(context.Background(), userID) }
+	leave := func(s *ChatService, userID string) {
+		s.EndChatSession(context.Background(), userID, entity.EndReasonDisconnected)
+	}
+
+	tests := []struct {
+		name       string
+		a, b       func(s *ChatService, userID string)
+		maxQueued  int // Times either user may be re-queued
+		wantQueued int // Users queued in the end
+	}{
+		{"both skip", skip, skip, 1, 2},
+		{"one skips as the other leaves", skip, leave, 1, -1},
+		{"both leave", leave, leave, 1, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			users := &countingQueue{UserRepository: memory.NewUserRepository(), queued: make(map[string]int)}
+			chats := newRacingChats(memory.NewChatRepository())
+			history := &countingHistory{}
+			s := NewChatService(chats, users, web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{}), WithChatHistory(history))
+
+			chat := &entity.Chat{ID: "chat-1", UserA: entity.User{UserID: "a"}, UserB: entity.User{UserID: "b"}, StartTime: time.Now()}
+			for _, userID := range []string{"a", "b"} {
+				if err := users.UpdateUserChatID(ctx, userID, chat.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := chats.SaveChatSession(ctx, chat); err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() { defer wg.Done(); test.a(s, "a") }()
+			go func() { defer wg.Done(); test.b(s, "b") }()
+			wg.Wait()
+			if err := s.waitForHistory(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if history.records != 1 {
+				t.Errorf("chat recorded %d times, want once", history.records)
+			}
+			for userID, times := range users.queued {
+				if times > test.maxQueued {
+					t.Errorf("user %s re-queued %d times", userID, times)
+				}
+			}
+			if length, _ := users.GetQueueLength(ctx); test.wantQueued >= 0 && length != test.wantQueued {
+				t.Errorf("queue length = %d, want %d", length, test.wantQueued)
+			}
+		})
+	}
+}
+
+func TestListenFromConnection(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	chats := memory.NewChatRepository()
+	hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+	s := NewChatService(chats, users, hub)
+
+	chat := &entity.Chat{ID: "chat-1", UserA: entity.User{UserID: "a"}, UserB: entity.User{UserID: "b"}, StartTime: time.Now()}
+	for _, userID := range []string{"a", "b"} {
+		if err := users.UpdateUserChatID(ctx, userID, chat.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := chats.SaveChatSession(ctx, chat); err != nil {
+		t.Fatal(err)
+	}
+	connA, connB := newFakeConnection(), newFakeConnection()
+	hub.AddConnection("a", connA)
+	hub.AddConnection("b", connB)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ListenFromConnection("a")
+	}()
+
+	// Chat messages reach the partner, stamped by the server
+	connA.send(t, &entity.Message{Type: entity.MessageTypeChat, Text: "hi", From: "spoofed"}, nil)
+	if got := connB.expect(t, entity.MessageTypeChat); got.Text != "hi" || got.From != "a" {
+		t.Errorf("partner got %q from %q, want \"hi\" from a", got.Text, got.From)
+	}
+
+	// An undecodable frame is answered and the loop keeps reading
+	connA.send(t, nil, repository.ErrInvalidMessage)
+	if got := connA.expect(t, entity.MessageTypeError); got.Code != entity.ErrCodeBadRequest {
+		t.Errorf("error code = %q, want %q", got.Code, entity.ErrCodeBadRequest)
+	}
+	connA.send(t, &entity.Message{Type: entity.MessageTypeTyping}, nil)
+	connB.expect(t, entity.MessageTypeTyping)
+
+	// A client close ends the chat and tells the partner
+	close(connA.frames)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ListenFromConnection did not return after the client closed")
+	}
+	connB.expect(t, entity.MessageTypePartnerLeft)
+	if _, err := chats.GetChatSession(ctx, chat.ID); err == nil {
+		t.Error("chat still exists after the user left")
+	}
+	if s.IsUserActive(ctx, "a") {
+		t.Error("user a is still active after closing the connection")
+	}
+}
+
+func TestPendingChallengeKeepsUserActive(t *testing.T) {
+	ctx := context.Background()
+	hub := web_socket_hub.NewWebSocketHub(web_socket_hub.ClientConfig{})
+	s := NewChatService(memory.NewChatRepository(), memory.NewUserRepository(), hub,
+		WithProofOfWork(ProofOfWork{Difficulty: 1, MaxDifficulty: 1, Timeout: time.Minute}))
+
+	conn := newFakeConnection()
+	hub.AddConnection("a", conn)
+	if err := s.JoinQueue(ctx, entity.User{UserID: "a", JoinTime: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(t, entity.MessageTypeChallenge)
+
+	// A second connection for the same user must not replace the one solving the challenge
+	if !s.IsUserActive(ctx, "a") {
+		t.Error("user solving a challenge is not active")
+	}
+	s.forgetChallenge("a")
+	if s.IsUserActive(ctx, "a") {
+		t.Error("user is still active after their connection ended")
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// transcriptFlushInterval is how often buffered transcript entries are written out
+const transcriptFlushInterval = time.Second
+
+// ErrTranscriptsDisabled is returned when transcripts are requested but not recorded
+var ErrTranscriptsDisabled = errors.New("transcripts are not recorded")
+
+// transcriptRecorder buffers each chat's messages and writes them out in batches
+type transcriptRecorder struct {
+	repo      repository.TranscriptRepository
+	retention time.Duration
+
+	mu      sync.Mutex
+	pending map[string][]entity.TranscriptEntry // Unwritten entries per chat
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WithTranscripts records the messages of every chat, kept for retention after the last message
+func WithTranscripts(transcriptRepo repository.TranscriptRepository, retention time.Duration) Option {
+	return func(s *ChatService) {
+		if retention <= 0 {
+			return
+		}
+		s.transcripts = &transcriptRecorder{
+			repo:      transcriptRepo,
+			retention: retention,
+			pending:   make(map[string][]entity.TranscriptEntry),
+			stop:      make(chan struct{}),
+			done:      make(chan struct{}),
+		}
+		go s.transcripts.run()
+	}
+}
+
+// GetTranscript returns a chat's decrypted transcript
+func (s *ChatService) GetTranscript(ctx context.Context, chatID string) ([]entity.TranscriptEntry, error) {
+	if s.transcripts == nil {
+		return nil, ErrTranscriptsDisabled
+	}
+	s.transcripts.flush() // Include messages still waiting in the buffer
+
+	entries, err := s.transcripts.repo.GetTranscript(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	// Both users' instances append, so batches can land out of order
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+// record buffers a forwarded chat message
+func (t *transcriptRecorder) record(chatID string, message *entity.Message) {
+	t.mu.Lock()
+	t.pending[chatID] = append(t.pending[chatID], entity.NewTranscriptEntry(message))
+	t.mu.Unlock()
+}
+
+// run writes buffered entries until close is called
+func (t *transcriptRecorder) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(transcriptFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			t.flush()
+			return
+		case <-ticker.C:
+			t.flush()
+		}
+	}
+}
+
+// flush writes every buffered entry; entries that fail to write are dropped
+func (t *transcriptRecorder) flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string][]entity.TranscriptEntry)
+	t.mu.Unlock()
+
+	for chatID, entries := range pending {
+		ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+		if err := t.repo.AppendTranscript(ctx, chatID, entries, t.retention); err != nil {
+			log.Printf("⚠️ Dropped %d transcript entries for chat %s: %v", len(entries), chatID, err)
+		}
+		cancel()
+	}
+}
+
+// close writes the remaining entries and stops the recorder, giving up when ctx is done
+func (t *transcriptRecorder) close(ctx context.Context) error {
+	close(t.stop)
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		log.Println("⚠️ Drain deadline reached with transcript entries pending")
+		return ctx.Err()
+	}
+}
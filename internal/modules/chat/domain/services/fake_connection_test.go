@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// frame is one read result a fakeConnection hands to the server
+type frame struct {
+	message *entity.Message
+	err     error
+}
+
+// fakeConnection is a repository.Connection driven by the test: frames are what
+// the client sends and sent collects what the server wrote back
+type fakeConnection struct {
+	frames    chan frame
+	sent      chan *entity.Message
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ repository.Connection = &fakeConnection{}
+
+func newFakeConnection() *fakeConnection {
+	return &fakeConnection{
+		frames: make(chan frame),
+		sent:   make(chan *entity.Message, 64),
+		closed: make(chan struct{}),
+	}
+}
+
+// ReadMessage returns the next frame; closing frames acts as a client close frame
+func (c *fakeConnection) ReadMessage() (*entity.Message, error) {
+	select {
+	case f, ok := <-c.frames:
+		if !ok {
+			return nil, repository.ErrClosedByClient
+		}
+		return f.message, f.err
+	case <-c.closed:
+		return nil, repository.ErrConnectionLost
+	}
+}
+
+func (c *fakeConnection) WriteMessage(message *entity.Message) error {
+	select {
+	case <-c.closed:
+		return errors.New("connection closed")
+	default:
+	}
+	select {
+	case c.sent <- message:
+		return nil
+	default:
+		return errors.New("test connection buffer full")
+	}
+}
+
+func (c *fakeConnection) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeConnection) RemoteAddr() string {
+	return "fake"
+}
+
+// send delivers a frame from the client, failing the test if the server stops reading
+func (c *fakeConnection) send(t *testing.T, message *entity.Message, err error) {
+	t.Helper()
+	select {
+	case c.frames <- frame{message: message, err: err}:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server is not reading from the connection")
+	}
+}
+
+// expect waits for the server to write a message of the given type, skipping any others
+func (c *fakeConnection) expect(t *testing.T, msgType entity.MessageType) *entity.Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case message := <-c.sent:
+			if message.Type == msgType {
+				return message
+			}
+		case <-timeout:
+			t.Fatalf("no %s message written", msgType)
+			return nil
+		}
+	}
+}
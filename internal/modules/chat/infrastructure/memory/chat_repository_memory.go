@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// updateBuffer is how many partner updates a subscriber may fall behind before updates are dropped,
+// mirroring a pub/sub subscriber that is not keeping up
+const updateBuffer = 16
+
+// ChatRepository keeps chat sessions in process memory for single-instance use
+type ChatRepository struct {
+	mu          sync.Mutex
+	chats       map[string]entity.Chat
+	subscribers map[string]map[chan *entity.User]struct{} // Partner update subscribers per user
+}
+
+// NewChatRepository initializes an in-memory chat repository
+func NewChatRepository() repository.ChatRepository {
+	return &ChatRepository{
+		chats:       make(map[string]entity.Chat),
+		subscribers: make(map[string]map[chan *entity.User]struct{}),
+	}
+}
+
+// SaveChatSession stores a copy of the chat session
+func (r *ChatRepository) SaveChatSession(ctx context.Context, chat *entity.Chat) error {
+	r.mu.Lock()
+	r.chats[chat.ID] = copyChat(*chat)
+	r.mu.Unlock()
+
+	log.Printf("Chat session started: %s <-> %s", chat.UserA.UserID, chat.UserB.UserID)
+	return nil
+}
+
+// GetChatSession returns a copy of the chat session
+func (r *ChatRepository) GetChatSession(ctx context.Context, chatID string) (*entity.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chat, exists := r.chats[chatID]
+	if !exists {
+		return nil, fmt.Errorf("chat session not found: %s", chatID)
+	}
+	chat = copyChat(chat)
+	return &chat, nil
+}
+
+// GetChatPartner retrieves the chat partner for a user
+func (r *ChatRepository) GetChatPartner(ctx context.Context, chatID, userID string) (*entity.User, error) {
+	chat, err := r.GetChatSession(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if chat.UserA.UserID == userID {
+		return &chat.UserB, nil
+	}
+	return &chat.UserA, nil
+}
+
+// IncrementMessageCount counts a chat message sent by userID in a live chat
+func (r *ChatRepository) IncrementMessageCount(ctx context.Context, chatID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chat, exists := r.chats[chatID]
+	if !exists {
+		return nil
+	}
+	if chat.MessageCounts == nil {
+		chat.MessageCounts = make(map[string]int64)
+	}
+	chat.MessageCounts[userID]++
+	r.chats[chatID] = chat
+	return nil
+}
+
+// DeleteChatSession removes a chat session, reporting whether this call removed it
+func (r *ChatRepository) DeleteChatSession(ctx context.Context, chatID string) (bool, error) {
+	r.mu.Lock()
+	_, exists := r.chats[chatID]
+	delete(r.chats, chatID)
+	r.mu.Unlock()
+
+	if !exists {
+		return false, nil
+	}
+	log.Printf("Chat session deleted: %s", chatID)
+	return true, nil
+}
+
+// SubscribeToChatUpdates delivers partner changes for the user until ctx is done
+func (r *ChatRepository) SubscribeToChatUpdates(ctx context.Context, userID string) <-chan *entity.User {
+	updates := make(chan *entity.User, updateBuffer)
+
+	r.mu.Lock()
+	if r.subscribers[userID] == nil {
+		r.subscribers[userID] = make(map[chan *entity.User]struct{})
+	}
+	r.subscribers[userID][updates] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers[userID], updates)
+		if len(r.subscribers[userID]) == 0 {
+			delete(r.subscribers, userID)
+		}
+		close(updates) // Under the lock, so NotifyPartnerUpdate never sends on a closed channel
+	}()
+
+	return updates
+}
+
+// NotifyPartnerUpdate publishes a new chat partner to the user's subscribers without blocking
+func (r *ChatRepository) NotifyPartnerUpdate(ctx context.Context, userID string, partner *entity.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for updates := range r.subscribers[userID] {
+		select {
+		case updates <- &entity.User{UserID: partner.UserID}:
+		default:
+			log.Printf("⚠️ Dropping partner update for %s: subscriber is not keeping up", userID)
+		}
+	}
+}
+
+// copyChat copies a chat so callers never share its tag slices or end time
+func copyChat(chat entity.Chat) entity.Chat {
+	chat.UserA = copyUser(chat.UserA)
+	chat.UserB = copyUser(chat.UserB)
+	if chat.MessageCounts != nil {
+		counts := make(map[string]int64, len(chat.MessageCounts))
+		for userID, count := range chat.MessageCounts {
+			counts[userID] = count
+		}
+		chat.MessageCounts = counts
+	}
+	if chat.EndTime != nil {
+		endTime := *chat.EndTime
+		chat.EndTime = &endTime
+	}
+	return chat
+}
@@ -0,0 +1,364 @@
+package memory
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// skipSweepInterval is how often MarkSkipped sweeps expired skips, so pairs that
+// are never checked again do not pile up
+const skipSweepInterval = time.Minute
+
+// queueEntry is a waiting user with the score that orders the queue
+type queueEntry struct {
+	userID string
+	score  int64 // Enqueue time in milliseconds; lower score = higher priority
+}
+
+// claim is a set of users taken off the queue and when they may be recovered
+type claim struct {
+	userIDs []string
+	until   time.Time
+}
+
+// UserRepository keeps users and the waiting queue in process memory for single-instance use.
+// The queue orders users like a Redis sorted set: by score, then by user ID.
+type UserRepository struct {
+	mu          sync.Mutex
+	users       map[string]entity.User
+	queue       map[string]int64     // Waiting users and their scores
+	skips       map[string]time.Time // Skipped pairs and when they may match again
+	claims      map[string]claim     // Claimed pairs not yet in a chat, by claimKey
+	lastSweep   time.Time            // When expired skips were last pruned
+	subscribers map[chan struct{}]struct{}
+}
+
+// NewUserRepository initializes an in-memory user repository
+func NewUserRepository() repository.UserRepository {
+	return &UserRepository{
+		users:       make(map[string]entity.User),
+		queue:       make(map[string]int64),
+		skips:       make(map[string]time.Time),
+		claims:      make(map[string]claim),
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+// AddUserToQueue stores the user and adds them to the queue, waking subscribers
+func (r *UserRepository) AddUserToQueue(ctx context.Context, user entity.User) error {
+	priority := time.Now().UnixMilli()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user = copyUser(user)
+	user.QueuedAt = time.Time{}
+	r.users[user.UserID] = user
+	r.queue[user.UserID] = priority
+
+	for wake := range r.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default: // A wake-up is already pending
+		}
+	}
+
+	log.Printf("User %s added to the queue with priority %d", user.UserID, priority)
+	return nil
+}
+
+// UpdateUserChatID updates the user's chat ID, creating a bare user record if none exists
+func (r *UserRepository) UpdateUserChatID(ctx context.Context, userID, chatID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists {
+		user = entity.User{UserID: userID}
+	}
+	user.ChatID = chatID
+	r.users[userID] = user
+
+	log.Printf("✅ Updated ChatID for user %s: %s", userID, chatID)
+	return nil
+}
+
+// SetShadow flags a user for the shadow pool; unknown users are left alone
+func (r *UserRepository) SetShadow(ctx context.Context, userID string, shadow bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, exists := r.users[userID]; exists {
+		user.Shadow = shadow
+		r.users[userID] = user
+	}
+	return nil
+}
+
+// GetUser returns a copy of the user, or nil if the user is unknown
+func (r *UserRepository) GetUser(ctx context.Context, userID string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists {
+		return nil, nil // User not found
+	}
+	user = copyUser(user)
+	return &user, nil
+}
+
+// PopTopUsers removes and returns the `limit` oldest waiting users
+func (r *UserRepository) PopTopUsers(ctx context.Context, limit int) ([]entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.sortedQueue(func(string) bool { return true }, limit)
+	if len(entries) == 0 {
+		log.Println("⚠️ No users found in queue")
+		return nil, nil
+	}
+
+	var users []entity.User
+	for _, entry := range entries {
+		delete(r.queue, entry.userID)
+		if user, exists := r.users[entry.userID]; exists {
+			users = append(users, copyUser(user))
+		}
+	}
+	return users, nil
+}
+
+// RemoveUser removes a user and takes them out of the queue
+func (r *UserRepository) RemoveUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.queue, userID)
+	delete(r.users, userID)
+	return nil
+}
+
+// GetQueueLength returns the number of users in the waiting queue
+func (r *UserRepository) GetQueueLength(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queue), nil
+}
+
+// PeekQueue returns up to `limit` of the oldest waiting users without removing them
+func (r *UserRepository) PeekQueue(ctx context.Context, limit int) ([]entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.queuedUsers(r.sortedQueue(func(string) bool { return true }, limit)), nil
+}
+
+// FindTagPartners returns up to `limit` users sharing an interest tag with `user`, longest-waiting first
+func (r *UserRepository) FindTagPartners(ctx context.Context, user entity.User, limit int) ([]entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.sortedQueue(func(userID string) bool {
+		return userID != user.UserID && sharesTag(user, r.users[userID])
+	}, limit)
+	return r.queuedUsers(entries), nil
+}
+
+// RemoveFromQueue takes users out of the waiting queue
+func (r *UserRepository) RemoveFromQueue(ctx context.Context, userIDs ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, userID := range userIDs {
+		delete(r.queue, userID)
+	}
+	return nil
+}
+
+// ClaimUsers takes all given users out of the queue, or none of them if any is no longer waiting,
+// and records the claim until ttl
+func (r *UserRepository) ClaimUsers(ctx context.Context, ttl time.Duration, userIDs ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, userID := range userIDs {
+		if _, waiting := r.queue[userID]; !waiting {
+			return false, nil
+		}
+	}
+	for _, userID := range userIDs {
+		delete(r.queue, userID)
+	}
+	r.claims[claimKey(userIDs)] = claim{
+		userIDs: append([]string(nil), userIDs...),
+		until:   time.Now().Add(ttl),
+	}
+	return true, nil
+}
+
+// ReleaseClaim clears the claim record of users whose pairing has finished
+func (r *UserRepository) ReleaseClaim(ctx context.Context, userIDs ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.claims, claimKey(userIDs))
+	return nil
+}
+
+// TakeExpiredClaims removes and returns the users of claims whose deadline has passed
+func (r *UserRepository) TakeExpiredClaims(ctx context.Context, now time.Time) ([][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired [][]string
+	for key, claim := range r.claims {
+		if now.After(claim.until) {
+			expired = append(expired, claim.userIDs)
+			delete(r.claims, key)
+		}
+	}
+	return expired, nil
+}
+
+// MarkSkipped keeps two users from being matched with each other for `ttl`
+func (r *UserRepository) MarkSkipped(ctx context.Context, userA, userB string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastSweep) >= skipSweepInterval {
+		r.pruneSkips(now)
+	}
+	r.skips[skipKey(userA, userB)] = now.Add(ttl)
+	return nil
+}
+
+// pruneSkips drops every skip that has expired by now. Callers must hold the lock.
+func (r *UserRepository) pruneSkips(now time.Time) {
+	for key, until := range r.skips {
+		if now.After(until) {
+			delete(r.skips, key)
+		}
+	}
+	r.lastSweep = now
+}
+
+// IsSkipped reports whether two users recently skipped each other
+func (r *UserRepository) IsSkipped(ctx context.Context, userA, userB string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := skipKey(userA, userB)
+	until, exists := r.skips[key]
+	if !exists {
+		return false, nil
+	}
+	if time.Now().After(until) {
+		delete(r.skips, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// SubscribeToQueue signals whenever a user is enqueued until ctx is done.
+// Bursts of enqueues collapse into a single pending signal.
+func (r *UserRepository) SubscribeToQueue(ctx context.Context) <-chan struct{} {
+	wake := make(chan struct{}, 1)
+
+	r.mu.Lock()
+	r.subscribers[wake] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers, wake)
+		close(wake)
+	}()
+
+	return wake
+}
+
+// sortedQueue returns up to `limit` queue entries accepted by `keep`, oldest first.
+// Callers must hold the lock.
+func (r *UserRepository) sortedQueue(keep func(userID string) bool, limit int) []queueEntry {
+	entries := make([]queueEntry, 0, len(r.queue))
+	for userID, score := range r.queue {
+		if keep(userID) {
+			entries = append(entries, queueEntry{userID: userID, score: score})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score < entries[j].score
+		}
+		return entries[i].userID < entries[j].userID
+	})
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// queuedUsers copies the users behind queue entries, stamped with when they were queued.
+// Callers must hold the lock.
+func (r *UserRepository) queuedUsers(entries []queueEntry) []entity.User {
+	users := make([]entity.User, 0, len(entries))
+	for _, entry := range entries {
+		user, exists := r.users[entry.userID]
+		if !exists {
+			continue
+		}
+		user = copyUser(user)
+		user.QueuedAt = time.UnixMilli(entry.score)
+		users = append(users, user)
+	}
+	return users
+}
+
+// skipKey returns the same key for a pair regardless of who skipped whom
+func skipKey(userA, userB string) string {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return userA + ":" + userB
+}
+
+// claimKey returns the same key for a claim regardless of the order of its users
+func claimKey(userIDs []string) string {
+	sorted := append([]string(nil), userIDs...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ":")
+}
+
+// sharesTag reports whether two users have at least one interest tag in common,
+// mirroring a lookup in the per-tag queues
+func sharesTag(userA, userB entity.User) bool {
+	for _, a := range userA.Tags {
+		for _, b := range userB.Tags {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyUser copies a user so callers never share its tag slice
+func copyUser(user entity.User) entity.User {
+	if user.Tags != nil {
+		user.Tags = append([]string(nil), user.Tags...)
+	}
+	return user
+}
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMarkSkippedPrunesExpiredSkips(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository().(*UserRepository)
+
+	for _, userID := range []string{"b", "c", "d"} {
+		if err := repo.MarkSkipped(ctx, "a", userID, time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Expired pairs that are never checked again go on the next sweep
+	repo.lastSweep = time.Time{}
+	if err := repo.MarkSkipped(ctx, "x", "y", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.skips) != 1 {
+		t.Errorf("%d skips kept, want only the live one", len(repo.skips))
+	}
+}
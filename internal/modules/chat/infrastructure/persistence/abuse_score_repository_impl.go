@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// abuseScoreLifetimes is how many half-lives a score is kept without new points; by then it is below 0.1%
+const abuseScoreLifetimes = 10
+
+// decayScoreScript decays a stored score to now and adds points to it. The score is
+// returned as a string, since Redis would truncate a Lua number to an integer.
+//
+// KEYS[1] = score hash
+// ARGV[1] = points, ARGV[2] = now (ms), ARGV[3] = half-life (ms), ARGV[4] = expiry (ms)
+var decayScoreScript = redis.NewScript(`
+local now = tonumber(ARGV[2])
+local score = tonumber(redis.call('HGET', KEYS[1], 'score') or '0')
+local updated = tonumber(redis.call('HGET', KEYS[1], 'at') or ARGV[2])
+local elapsed = math.max(0, now - updated)
+
+score = score * math.pow(0.5, elapsed / tonumber(ARGV[3])) + tonumber(ARGV[1])
+redis.call('HSET', KEYS[1], 'score', tostring(score), 'at', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return tostring(score)
+`)
+
+// AbuseScoreRepository keeps decaying abuse scores in Redis
+type AbuseScoreRepository struct {
+	client *redis.Client
+}
+
+// NewAbuseScoreRepository initializes a Redis abuse score repository
+func NewAbuseScoreRepository(client *redis.Client) repository.AbuseScoreRepository {
+	return &AbuseScoreRepository{client: client}
+}
+
+// AddScore adds points to a user's score after decaying it to now
+func (r *AbuseScoreRepository) AddScore(ctx context.Context, userID string, points float64, halfLife time.Duration) (float64, error) {
+	halfLifeMs := halfLife.Milliseconds()
+	if halfLifeMs <= 0 {
+		return 0, fmt.Errorf("abuse score half-life must be at least 1ms")
+	}
+
+	raw, err := decayScoreScript.Run(ctx, r.client, []string{abuseScoreKey(userID)},
+		points, time.Now().UnixMilli(), halfLifeMs, halfLifeMs*abuseScoreLifetimes).Text()
+	if err != nil {
+		log.Printf("❌ Error updating abuse score for %s: %v", userID, err)
+		return 0, err
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// GetScore reads a user's score decayed to now, leaving the stored score and its expiry untouched
+func (r *AbuseScoreRepository) GetScore(ctx context.Context, userID string, halfLife time.Duration) (float64, error) {
+	halfLifeMs := halfLife.Milliseconds()
+	if halfLifeMs <= 0 {
+		return 0, fmt.Errorf("abuse score half-life must be at least 1ms")
+	}
+
+	fields, err := r.client.HMGet(ctx, abuseScoreKey(userID), "score", "at").Result()
+	if err != nil {
+		log.Printf("❌ Error reading abuse score for %s: %v", userID, err)
+		return 0, err
+	}
+	rawScore, _ := fields[0].(string)
+	rawAt, _ := fields[1].(string)
+	if rawScore == "" {
+		return 0, nil
+	}
+
+	score, err := strconv.ParseFloat(rawScore, 64)
+	if err != nil {
+		return 0, err
+	}
+	at, err := strconv.ParseFloat(rawAt, 64) // Written by Lua, which may format it as a float
+	if err != nil {
+		return 0, err
+	}
+	elapsed := max(0, float64(time.Now().UnixMilli())-at)
+	return score * math.Pow(0.5, elapsed/float64(halfLifeMs)), nil
+}
+
+// abuseScoreKey returns the key holding a user's abuse score
+func abuseScoreKey(userID string) string {
+	return fmt.Sprintf("abuse:%s", userID)
+}
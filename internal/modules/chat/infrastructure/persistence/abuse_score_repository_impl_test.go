@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetScoreDoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	repo := NewAbuseScoreRepository(client)
+
+	// Users who never scored read as 0 and get no hash
+	if score, err := repo.GetScore(ctx, "clean", time.Hour); err != nil || score != 0 {
+		t.Fatalf("GetScore of a clean user = %v, %v; want 0", score, err)
+	}
+	if server.Exists(abuseScoreKey("clean")) {
+		t.Error("reading a clean user's score created a hash")
+	}
+
+	if _, err := repo.AddScore(ctx, "flagged", 10, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	before := server.TTL(abuseScoreKey("flagged"))
+	server.FastForward(time.Minute)
+
+	score, err := repo.GetScore(ctx, "flagged", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(score-10) > 0.01 {
+		t.Errorf("GetScore = %v, want about 10", score)
+	}
+	if after := server.TTL(abuseScoreKey("flagged")); after != before-time.Minute {
+		t.Errorf("GetScore refreshed the expiry: TTL %s, want %s", after, before-time.Minute)
+	}
+}
+
+func TestGetScoreDecays(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	repo := NewAbuseScoreRepository(client)
+
+	// A score written one half-life ago has halved
+	halfLife := time.Hour
+	server.HSet(abuseScoreKey("old"), "score", "20", "at", strconv.FormatInt(time.Now().Add(-halfLife).UnixMilli(), 10))
+
+	score, err := repo.GetScore(ctx, "old", halfLife)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(score-10) > 0.01 {
+		t.Errorf("GetScore = %v, want about 10", score)
+	}
+}
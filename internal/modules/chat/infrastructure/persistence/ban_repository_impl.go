@@ -0,0 +1,198 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// Keys indexing bans; each ban itself lives under ban:<id> and expires with the ban
+const (
+	banIndexKey = "bans"    // Sorted set of ban IDs by creation time
+	ipBansKey   = "bans:ip" // Set of IP ban IDs, matched by range on lookup
+)
+
+// BanRepository keeps bans in Redis. User and device bans are found through
+// ban:<kind>:<value> pointers; IP ranges are scanned, as there are few of them.
+type BanRepository struct {
+	client *redis.Client
+}
+
+// NewBanRepository initializes a Redis ban repository
+func NewBanRepository(client *redis.Client) repository.BanRepository {
+	return &BanRepository{client: client}
+}
+
+// CreateBan stores a ban until it expires
+func (r *BanRepository) CreateBan(ctx context.Context, ban *entity.Ban) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration // Zero keeps a permanent ban forever
+	if ban.ExpiresAt != nil {
+		ttl = time.Until(*ban.ExpiresAt)
+		if ttl <= 0 {
+			return nil // Already over
+		}
+	}
+
+	// A new user or device ban replaces the previous one on the same value
+	var previousID string
+	if ban.Kind != entity.BanKindIP {
+		previousID, err = r.client.Get(ctx, banLookupKey(ban.Kind, ban.Value)).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+	}
+
+	pipe := r.client.TxPipeline()
+	if previousID != "" {
+		pipe.Del(ctx, banKey(previousID))
+		pipe.ZRem(ctx, banIndexKey, previousID)
+	}
+	pipe.Set(ctx, banKey(ban.ID), data, ttl)
+	pipe.ZAdd(ctx, banIndexKey, redis.Z{Score: float64(ban.CreatedAt.UnixMilli()), Member: ban.ID})
+	if ban.Kind == entity.BanKindIP {
+		pipe.SAdd(ctx, ipBansKey, ban.ID)
+	} else {
+		pipe.Set(ctx, banLookupKey(ban.Kind, ban.Value), ban.ID, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("❌ Error storing ban %s: %v", ban.ID, err)
+		return err
+	}
+	return nil
+}
+
+// FindBan returns the active ban on a kind and value
+func (r *BanRepository) FindBan(ctx context.Context, kind entity.BanKind, value string) (*entity.Ban, error) {
+	if kind == entity.BanKindIP {
+		return r.findIPBan(ctx, value)
+	}
+
+	banID, err := r.client.Get(ctx, banLookupKey(kind, value)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := r.getBans(ctx, "", banID)
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+	return &bans[0], nil
+}
+
+// findIPBan returns an IP ban whose range covers ip, preferring one that blocks
+func (r *BanRepository) findIPBan(ctx context.Context, ip string) (*entity.Ban, error) {
+	banIDs, err := r.client.SMembers(ctx, ipBansKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := r.getBans(ctx, ipBansKey, banIDs...)
+	if err != nil {
+		return nil, err
+	}
+	var shadow *entity.Ban
+	for i := range bans {
+		if !bans[i].MatchesIP(ip) {
+			continue
+		}
+		if !bans[i].Shadow() {
+			return &bans[i], nil
+		}
+		if shadow == nil {
+			shadow = &bans[i]
+		}
+	}
+	return shadow, nil
+}
+
+// ListBans returns active bans, newest first
+func (r *BanRepository) ListBans(ctx context.Context, limit int) ([]entity.Ban, error) {
+	banIDs, err := r.client.ZRevRange(ctx, banIndexKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return r.getBans(ctx, banIndexKey, banIDs...)
+}
+
+// DeleteBan lifts a ban and removes it from every index
+func (r *BanRepository) DeleteBan(ctx context.Context, banID string) error {
+	bans, err := r.getBans(ctx, "", banID)
+	if err != nil {
+		return err
+	}
+	if len(bans) == 0 {
+		return repository.ErrBanNotFound
+	}
+	ban := bans[0]
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, banKey(ban.ID))
+	pipe.ZRem(ctx, banIndexKey, ban.ID)
+	if ban.Kind == entity.BanKindIP {
+		pipe.SRem(ctx, ipBansKey, ban.ID)
+	} else {
+		pipe.Del(ctx, banLookupKey(ban.Kind, ban.Value))
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// getBans loads bans by ID, pruning expired ones from index when it is set
+func (r *BanRepository) getBans(ctx context.Context, index string, banIDs ...string) ([]entity.Ban, error) {
+	if len(banIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(banIDs))
+	for i, banID := range banIDs {
+		keys[i] = banKey(banID)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]entity.Ban, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			if index == ipBansKey {
+				r.client.SRem(ctx, index, banIDs[i])
+			} else if index != "" {
+				r.client.ZRem(ctx, index, banIDs[i])
+			}
+			continue
+		}
+		var ban entity.Ban
+		if err := json.Unmarshal([]byte(data), &ban); err != nil {
+			log.Printf("⚠️ Skipping undecodable ban %s: %v", banIDs[i], err)
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+// banKey returns the key holding a ban
+func banKey(banID string) string {
+	return fmt.Sprintf("ban:%s", banID)
+}
+
+// banLookupKey returns the key pointing at the ban on a user or device
+func banLookupKey(kind entity.BanKind, value string) string {
+	return fmt.Sprintf("ban:%s:%s", kind, value)
+}
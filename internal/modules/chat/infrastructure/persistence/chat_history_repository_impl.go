@@ -0,0 +1,36 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// ChatHistoryRepository stores ended chats in PostgreSQL
+type ChatHistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewChatHistoryRepository initializes a PostgreSQL chat history repository
+func NewChatHistoryRepository(pool *pgxpool.Pool) repository.ChatHistoryRepository {
+	return &ChatHistoryRepository{pool: pool}
+}
+
+// RecordChat inserts an ended chat; both users ending the same chat leave one record
+func (r *ChatHistoryRepository) RecordChat(ctx context.Context, record entity.ChatRecord) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO chat_history
+			(chat_id, user_a, user_b, start_time, end_time, end_reason, ended_by, messages_a, messages_b, node_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (chat_id) DO NOTHING`,
+		record.ChatID, record.UserA, record.UserB, record.StartTime, record.EndTime,
+		string(record.EndReason), record.EndedBy, record.MessagesA, record.MessagesB, record.NodeID,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording chat %s: %v", record.ChatID, err)
+	}
+	return nil
+}
@@ -5,12 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 )
 
+// messageCountPrefix prefixes the chat hash fields counting each user's messages
+const messageCountPrefix = "messages:"
+
+// incrementIfExistsScript counts a message without recreating the hash of an ended chat
+var incrementIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+`)
+
 // RedisChatRepository handles chat session storage in Redis
 type ChatRepository struct {
 	client *redis.Client
@@ -65,6 +78,16 @@ func (r *ChatRepository) GetChatSession(ctx context.Context, chatID string) (*en
 		return nil, fmt.Errorf("error decoding chat session: %v", err)
 	}
 
+	// Message counts live in their own fields so senders can increment them atomically
+	for field, value := range data {
+		if userID, ok := strings.CutPrefix(field, messageCountPrefix); ok {
+			if chat.MessageCounts == nil {
+				chat.MessageCounts = make(map[string]int64)
+			}
+			chat.MessageCounts[userID], _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
 	return &chat, nil
 }
 
@@ -83,22 +106,29 @@ func (r *ChatRepository) GetChatPartner(ctx context.Context, chatID, userID stri
 	return &chat.UserA, nil
 }
 
-// DeleteChatSession removes a chat session from Redis
-func (r *ChatRepository) DeleteChatSession(ctx context.Context, chatID string) error {
+// IncrementMessageCount counts a chat message sent by userID in a live chat
+func (r *ChatRepository) IncrementMessageCount(ctx context.Context, chatID, userID string) error {
 	chatKey := fmt.Sprintf("chat:%s", chatID)
+	return incrementIfExistsScript.Run(ctx, r.client, []string{chatKey}, messageCountPrefix+userID).Err()
+}
 
-		// Delete chat session
-	err := r.client.Del(ctx, chatKey).Err()
+// DeleteChatSession removes a chat session from Redis, reporting whether this call removed it
+func (r *ChatRepository) DeleteChatSession(ctx context.Context, chatID string) (bool, error) {
+	chatKey := fmt.Sprintf("chat:%s", chatID)
+
+	// Delete chat session; DEL counts only the keys this call removed
+	deleted, err := r.client.Del(ctx, chatKey).Result()
 	if err != nil {
 		log.Printf("Error deleting chat session: %v", err)
-		return err
+		return false, err
+	}
+	if deleted == 0 {
+		return false, nil
 	}
 	log.Printf("Chat session deleted: %s", chatID)
-	return nil
+	return true, nil
 }
 
-
-
 // SubscribeToChatUpdates listens for partner changes in Redis.
 func (r *ChatRepository) SubscribeToChatUpdates(ctx context.Context, userID string) <-chan *entity.User {
 	channel := "chat_updates:" + userID
@@ -108,11 +138,24 @@ func (r *ChatRepository) SubscribeToChatUpdates(ctx context.Context, userID stri
 
 	go func() {
 		defer sub.Close()
-		for msg := range sub.Channel() {
-			partner := &entity.User{UserID: msg.Payload}
-			updates <- partner
+		defer close(updates) // Close when subscription ends
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				partner := &entity.User{UserID: msg.Payload}
+				select {
+				case updates <- partner:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}
-		close(updates) // Close when subscription ends
 	}()
 
 	return updates
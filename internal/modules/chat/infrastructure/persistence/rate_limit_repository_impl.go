@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// slidingWindowScript approximates a sliding window from two fixed windows: the
+// previous window's count is weighted by how much of it still overlaps the sliding one.
+//
+// KEYS[1] = current window, KEYS[2] = previous window
+// ARGV[1] = limit, ARGV[2] = cost, ARGV[3] = window (ms), ARGV[4] = elapsed in current window (ms)
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local cost = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local weight = (window - tonumber(ARGV[4])) / window
+
+if previous * weight + current + cost > limit then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], cost)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return 1
+`)
+
+// slidingCountScript records one event and returns the sliding window's count, rounded up.
+//
+// KEYS[1] = current window, KEYS[2] = previous window
+// ARGV[1] = window (ms), ARGV[2] = elapsed in current window (ms)
+var slidingCountScript = redis.NewScript(`
+local current = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], tonumber(ARGV[1]) * 2)
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local window = tonumber(ARGV[1])
+local weight = (window - tonumber(ARGV[2])) / window
+return math.ceil(previous * weight + current)
+`)
+
+// RateLimitRepository keeps sliding-window counters in Redis
+type RateLimitRepository struct {
+	client *redis.Client
+}
+
+// NewRateLimitRepository initializes a Redis rate limit repository
+func NewRateLimitRepository(client *redis.Client) repository.RateLimitRepository {
+	return &RateLimitRepository{client: client}
+}
+
+// Allow records `cost` units against `key` if the sliding window stays within `limit`
+func (r *RateLimitRepository) Allow(ctx context.Context, key string, limit int64, window time.Duration, cost int64) (bool, error) {
+	windowMs := window.Milliseconds()
+	if windowMs <= 0 {
+		return false, fmt.Errorf("rate limit window for %s must be at least 1ms", key)
+	}
+	nowMs := time.Now().UnixMilli()
+	index := nowMs / windowMs
+
+	keys := []string{rateLimitKey(key, index), rateLimitKey(key, index-1)}
+	allowed, err := slidingWindowScript.Run(ctx, r.client, keys, limit, cost, windowMs, nowMs%windowMs).Int()
+	if err != nil {
+		log.Printf("❌ Error checking rate limit %s: %v", key, err)
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+// Hit records one event against `key` and returns the sliding window's count
+func (r *RateLimitRepository) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	windowMs := window.Milliseconds()
+	if windowMs <= 0 {
+		return 0, fmt.Errorf("rate limit window for %s must be at least 1ms", key)
+	}
+	nowMs := time.Now().UnixMilli()
+	index := nowMs / windowMs
+
+	keys := []string{rateLimitKey(key, index), rateLimitKey(key, index-1)}
+	count, err := slidingCountScript.Run(ctx, r.client, keys, windowMs, nowMs%windowMs).Int64()
+	if err != nil {
+		log.Printf("❌ Error counting %s: %v", key, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// rateLimitKey returns the counter for one fixed window of a limit
+func rateLimitKey(key string, index int64) string {
+	return fmt.Sprintf("ratelimit:%s:%d", key, index)
+}
@@ -0,0 +1,218 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/common/encryption"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// resolveAttempts bounds retries when a report changes while it is being resolved
+const resolveAttempts = 3
+
+// ReportRepository keeps reports as JSON under report:<id>, indexed by status in sorted sets
+type ReportRepository struct {
+	client    *redis.Client
+	retention time.Duration      // How long a report is kept after it was filed
+	sealer    *encryption.Sealer // Encrypts the messages attached to reports; nil stores them in plaintext
+}
+
+// storedReport is a report as kept in Redis, its messages sealed when a key is configured
+type storedReport struct {
+	entity.Report
+	SealedMessages []byte `json:"sealed_messages,omitempty"`
+}
+
+// NewReportRepository initializes a Redis moderation queue. With a sealer, the messages
+// attached to reports are encrypted like transcripts; without one they are stored as sent.
+func NewReportRepository(client *redis.Client, retention time.Duration, sealer *encryption.Sealer) repository.ReportRepository {
+	return &ReportRepository{client: client, retention: retention, sealer: sealer}
+}
+
+// CreateReport stores the report and adds it to the open queue
+func (r *ReportRepository) CreateReport(ctx context.Context, report *entity.Report) error {
+	first, err := r.client.SetNX(ctx, fmt.Sprintf("report:dedupe:%s:%s", report.ChatID, report.ReporterID), report.ID, r.retention).Result()
+	if err != nil {
+		return err
+	}
+	if !first {
+		return repository.ErrDuplicateReport
+	}
+
+	data, err := r.encode(report)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, reportKey(report.ID), data, r.retention)
+	pipe.ZAdd(ctx, reportIndex(entity.ReportStatusOpen), redis.Z{Score: float64(report.CreatedAt.UnixMilli()), Member: report.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("❌ Error storing report %s: %v", report.ID, err)
+		return err
+	}
+	return nil
+}
+
+// GetReport retrieves a report by ID
+func (r *ReportRepository) GetReport(ctx context.Context, reportID string) (*entity.Report, error) {
+	data, err := r.client.Get(ctx, reportKey(reportID)).Bytes()
+	if err == redis.Nil {
+		return nil, repository.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding report %s: %v", reportID, err)
+	}
+	return report, nil
+}
+
+// ListReports returns reports with the given status; expired reports are pruned from the index
+func (r *ReportRepository) ListReports(ctx context.Context, status entity.ReportStatus, limit int) ([]entity.Report, error) {
+	index := reportIndex(status)
+	query := r.client.ZRange
+	if status == entity.ReportStatusResolved {
+		query = r.client.ZRevRange
+	}
+
+	reportIDs, err := query(ctx, index, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(reportIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(reportIDs))
+	for i, reportID := range reportIDs {
+		keys[i] = reportKey(reportID)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]entity.Report, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			r.client.ZRem(ctx, index, reportIDs[i]) // Past retention
+			continue
+		}
+		report, err := r.decode([]byte(data))
+		if err != nil {
+			log.Printf("⚠️ Skipping undecodable report %s: %v", reportIDs[i], err)
+			continue
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+// ResolveReport records the decision and moves the report to the resolved index
+func (r *ReportRepository) ResolveReport(ctx context.Context, reportID string, resolution entity.ReportResolution) (*entity.Report, error) {
+	key := reportKey(reportID)
+
+	var resolved *entity.Report
+	resolve := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return repository.ErrReportNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		report, err := r.decode(data)
+		if err != nil {
+			return fmt.Errorf("error decoding report %s: %v", reportID, err)
+		}
+		if report.Status != entity.ReportStatusOpen {
+			return repository.ErrReportResolved
+		}
+		report.Status = entity.ReportStatusResolved
+		report.Resolution = &resolution
+
+		updated, err := r.encode(report)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SetArgs(ctx, key, updated, redis.SetArgs{KeepTTL: true})
+			pipe.ZRem(ctx, reportIndex(entity.ReportStatusOpen), reportID)
+			pipe.ZAdd(ctx, reportIndex(entity.ReportStatusResolved), redis.Z{Score: float64(resolution.ResolvedAt.UnixMilli()), Member: reportID})
+			return nil
+		})
+		if err == nil {
+			resolved = report
+		}
+		return err
+	}
+
+	for attempt := 0; attempt < resolveAttempts; attempt++ {
+		err := r.client.Watch(ctx, resolve, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue // Another moderator touched the report; look again
+		}
+		return resolved, err
+	}
+	return nil, fmt.Errorf("report %s kept changing while being resolved", reportID)
+}
+
+// encode renders a report for storage, sealing its messages when a key is configured
+func (r *ReportRepository) encode(report *entity.Report) ([]byte, error) {
+	stored := storedReport{Report: *report}
+	if r.sealer != nil && len(report.Messages) > 0 {
+		messages, err := json.Marshal(report.Messages)
+		if err != nil {
+			return nil, err
+		}
+		if stored.SealedMessages, err = r.sealer.Seal(messages); err != nil {
+			return nil, fmt.Errorf("error encrypting report messages: %v", err)
+		}
+		stored.Messages = nil
+	}
+	return json.Marshal(stored)
+}
+
+// decode parses a stored report, opening its sealed messages
+func (r *ReportRepository) decode(data []byte) (*entity.Report, error) {
+	var stored storedReport
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if len(stored.SealedMessages) > 0 {
+		if r.sealer == nil {
+			return nil, errors.New("report messages are sealed but no key is configured")
+		}
+		messages, err := r.sealer.Open(stored.SealedMessages)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting report messages: %v", err)
+		}
+		if err := json.Unmarshal(messages, &stored.Messages); err != nil {
+			return nil, err
+		}
+	}
+	return &stored.Report, nil
+}
+
+// reportKey returns the key holding a report
+func reportKey(reportID string) string {
+	return fmt.Sprintf("report:%s", reportID)
+}
+
+// reportIndex returns the sorted set indexing reports with a status
+func reportIndex(status entity.ReportStatus) string {
+	return fmt.Sprintf("reports:%s", status)
+}
@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/common/encryption"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+)
+
+func TestReportMessagesAreSealed(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	sealer, err := encryption.NewSealer(make([]byte, encryption.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewReportRepository(client, time.Hour, sealer)
+
+	report := entity.NewReport("chat-1", "a", "b", entity.ReportReasonHarassment, "")
+	report.Messages = []entity.TranscriptEntry{entity.NewTranscriptEntry(entity.NewChatMessage("b", "my phone is 555"))}
+	if err := repo.CreateReport(ctx, report); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := server.Get(reportKey(report.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stored, "555") {
+		t.Errorf("report stored with plaintext messages: %s", stored)
+	}
+
+	// Messages survive reading and resolving
+	got, err := repo.GetReport(ctx, report.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Text != "my phone is 555" {
+		t.Errorf("messages = %+v, want the sealed message back", got.Messages)
+	}
+	resolved, err := repo.ResolveReport(ctx, report.ID, entity.ReportResolution{Action: entity.ReportActionDismiss, ResolvedBy: "mod", ResolvedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved.Messages) != 1 {
+		t.Errorf("resolved report has %d messages, want 1", len(resolved.Messages))
+	}
+	if listed, _ := repo.ListReports(ctx, entity.ReportStatusResolved, 10); len(listed) != 1 || len(listed[0].Messages) != 1 {
+		t.Errorf("listed reports = %+v, want one with its message", listed)
+	}
+	if stored, _ := server.Get(reportKey(report.ID)); strings.Contains(stored, "555") {
+		t.Errorf("resolved report stored with plaintext messages: %s", stored)
+	}
+}
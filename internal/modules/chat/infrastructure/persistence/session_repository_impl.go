@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// SessionRepository stores suspended sessions in Redis
+type SessionRepository struct {
+	client *redis.Client
+}
+
+// NewSessionRepository initializes a Redis session repository
+func NewSessionRepository(client *redis.Client) repository.SessionRepository {
+	return &SessionRepository{client: client}
+}
+
+// Suspend records the resume deadline. The key outlives the deadline so that
+// whoever claims it later can still tell a late resume from an expiry.
+func (r *SessionRepository) Suspend(ctx context.Context, userID string, grace time.Duration) error {
+	deadline := time.Now().Add(grace).UnixMilli()
+	err := r.client.Set(ctx, resumeKey(userID), deadline, 2*grace).Err()
+	if err != nil {
+		log.Printf("❌ Error suspending session for %s: %v", userID, err)
+	}
+	return err
+}
+
+// Resume claims the suspended session if its deadline has not passed.
+// A late resume leaves the key for Expire, so the session is still torn down.
+func (r *SessionRepository) Resume(ctx context.Context, userID string) (bool, error) {
+	value, err := r.client.Get(ctx, resumeKey(userID)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("❌ Error reading session for %s: %v", userID, err)
+		return false, err
+	}
+
+	deadline, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || time.Now().UnixMilli() > deadline {
+		return false, err
+	}
+	return r.claim(ctx, userID)
+}
+
+// Expire claims the suspended session so its owner can tear it down
+func (r *SessionRepository) Expire(ctx context.Context, userID string) (bool, error) {
+	return r.claim(ctx, userID)
+}
+
+// claim deletes the suspension, reporting whether this caller was the one to remove it
+func (r *SessionRepository) claim(ctx context.Context, userID string) (bool, error) {
+	deleted, err := r.client.Del(ctx, resumeKey(userID)).Result()
+	if err != nil {
+		log.Printf("❌ Error claiming session for %s: %v", userID, err)
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+// resumeKey returns the key holding a suspended user's resume deadline
+func resumeKey(userID string) string {
+	return fmt.Sprintf("resume:%s", userID)
+}
@@ -0,0 +1,83 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/common/encryption"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// TranscriptRepository keeps transcripts in Redis lists, one sealed entry per element
+type TranscriptRepository struct {
+	client *redis.Client
+	sealer *encryption.Sealer
+}
+
+// NewTranscriptRepository initializes a Redis transcript repository encrypting with sealer
+func NewTranscriptRepository(client *redis.Client, sealer *encryption.Sealer) repository.TranscriptRepository {
+	return &TranscriptRepository{client: client, sealer: sealer}
+}
+
+// AppendTranscript seals and appends entries; the whole transcript expires retention after the last append
+func (r *TranscriptRepository) AppendTranscript(ctx context.Context, chatID string, entries []entity.TranscriptEntry, retention time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	sealed := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		value, err := r.sealer.Seal(data)
+		if err != nil {
+			return fmt.Errorf("error encrypting transcript entry: %v", err)
+		}
+		sealed = append(sealed, value)
+	}
+
+	key := transcriptKey(chatID)
+	pipe := r.client.TxPipeline()
+	pipe.RPush(ctx, key, sealed...)
+	pipe.Expire(ctx, key, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error appending transcript for chat %s: %v", chatID, err)
+	}
+	return nil
+}
+
+// GetTranscript reads and decrypts a chat's transcript
+func (r *TranscriptRepository) GetTranscript(ctx context.Context, chatID string) ([]entity.TranscriptEntry, error) {
+	values, err := r.client.LRange(ctx, transcriptKey(chatID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repository.ErrTranscriptNotFound
+	}
+
+	entries := make([]entity.TranscriptEntry, 0, len(values))
+	for _, value := range values {
+		data, err := r.sealer.Open([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting transcript for chat %s: %v", chatID, err)
+		}
+		var entry entity.TranscriptEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("error decoding transcript for chat %s: %v", chatID, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// transcriptKey returns the list holding a chat's sealed transcript
+func transcriptKey(chatID string) string {
+	return fmt.Sprintf("transcript:%s", chatID)
+}
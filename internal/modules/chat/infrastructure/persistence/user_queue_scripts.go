@@ -0,0 +1,40 @@
+package persistence
+
+import "github.com/redis/go-redis/v9"
+
+// claimUsersScript atomically removes every given user from the waiting queue
+// and their tag queues, but only if all of them are still waiting. Because the
+// presence check and removal happen in one script, two workers can never pair
+// the same user. The claim is recorded with a deadline so that users stranded
+// by a worker dying before their chat exists can be re-queued.
+//
+// KEYS[1] = waiting queue, KEYS[2] = claim records, KEYS[3..] = tag queues of the users
+// ARGV[1] = claim record member, ARGV[2] = deadline (ms), ARGV[3..] = user IDs
+// Returns 1 when the users were claimed, 0 when any of them was already gone.
+var claimUsersScript = redis.NewScript(`
+local userIDs = {}
+for i = 3, #ARGV do
+	if not redis.call('ZSCORE', KEYS[1], ARGV[i]) then
+		return 0
+	end
+	userIDs[#userIDs + 1] = ARGV[i]
+end
+
+redis.call('ZREM', KEYS[1], unpack(userIDs))
+for i = 3, #KEYS do
+	redis.call('ZREM', KEYS[i], unpack(userIDs))
+end
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[1])
+
+return 1
+`)
+
+// setIfExistsScript sets a field on a user hash that still exists, so a late
+// update never recreates a user that has already left
+var setIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
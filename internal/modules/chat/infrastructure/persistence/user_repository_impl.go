@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -28,7 +31,7 @@ func NewUserRepository(client *redis.Client, queueName string) repository.UserRe
 
 // AddUserToQueue stores user entity in Redis and adds them to the queue
 func (r *UserRepository) AddUserToQueue(ctx context.Context, user entity.User) error {
-	priority := float64(time.Now().Unix()) // Lower score = higher priority
+	priority := float64(time.Now().UnixMilli()) // Lower score = higher priority
 
 	// Store user in Redis Hash
 	userKey := fmt.Sprintf("user:%s", user.UserID)
@@ -38,6 +41,10 @@ func (r *UserRepository) AddUserToQueue(ctx context.Context, user entity.User) e
 		"chatID":   user.ChatID,
 		"joinTime": user.JoinTime.Unix(),
 		"chatted":  user.Chatted,
+		"tags":     strings.Join(user.Tags, ","),
+		"ip":       user.Client.IP,
+		"device":   user.Client.DeviceID,
+		"shadow":   user.Shadow,
 		"data":     userData,
 	}).Result()
 
@@ -57,10 +64,89 @@ func (r *UserRepository) AddUserToQueue(ctx context.Context, user entity.User) e
 		return err
 	}
 
+	// Add user to every interest tag queue
+	for _, tag := range user.Tags {
+		err = r.client.ZAdd(ctx, r.tagQueue(tag), redis.Z{
+			Score:  priority,
+			Member: user.UserID,
+		}).Err()
+		if err != nil {
+			log.Printf("Error adding user to tag queue %s: %v", tag, err)
+			return err
+		}
+	}
+
+	// Wake matchmaking workers on every instance
+	if err := r.client.Publish(ctx, r.queueEvents(), user.UserID).Err(); err != nil {
+		log.Printf("⚠️ Failed to publish queue event for %s: %v", user.UserID, err)
+	}
+
 	log.Printf("User %s added to the queue with priority %.0f", user.UserID, priority)
 	return nil
 }
 
+// SetShadow flags a user for the shadow pool; unknown users are left alone
+func (r *UserRepository) SetShadow(ctx context.Context, userID string, shadow bool) error {
+	userKey := fmt.Sprintf("user:%s", userID)
+	if _, err := setIfExistsScript.Run(ctx, r.client, []string{userKey}, "shadow", shadow).Result(); err != nil && err != redis.Nil {
+		log.Printf("❌ Error updating shadow flag for user %s: %v", userID, err)
+		return err
+	}
+	return nil
+}
+
+// MarkSkipped keeps two users from being matched with each other for `ttl`
+func (r *UserRepository) MarkSkipped(ctx context.Context, userA, userB string, ttl time.Duration) error {
+	return r.client.Set(ctx, skipKey(userA, userB), 1, ttl).Err()
+}
+
+// IsSkipped reports whether two users recently skipped each other
+func (r *UserRepository) IsSkipped(ctx context.Context, userA, userB string) (bool, error) {
+	count, err := r.client.Exists(ctx, skipKey(userA, userB)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// skipKey returns the same key for a pair regardless of who skipped whom
+func skipKey(userA, userB string) string {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return fmt.Sprintf("skip:%s:%s", userA, userB)
+}
+
+// SubscribeToQueue signals whenever a user is enqueued on any instance.
+// Bursts of enqueues collapse into a single pending signal.
+func (r *UserRepository) SubscribeToQueue(ctx context.Context) <-chan struct{} {
+	sub := r.client.Subscribe(ctx, r.queueEvents())
+	messages := sub.Channel()
+
+	wake := make(chan struct{}, 1)
+
+	go func() {
+		defer sub.Close()
+		defer close(wake)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case wake <- struct{}{}:
+				default: // A wake-up is already pending
+				}
+			}
+		}
+	}()
+
+	return wake
+}
+
 // GetUser retrieves a user entity from Redis
 func (r *UserRepository) GetUser(ctx context.Context, userID string) (*entity.User, error) {
 	userKey := fmt.Sprintf("user:%s", userID)
@@ -72,27 +158,27 @@ func (r *UserRepository) GetUser(ctx context.Context, userID string) (*entity.Us
 
 	user := &entity.User{
 		UserID:   userID,
-		ChatID: data["chatID"],
+		ChatID:   data["chatID"],
 		JoinTime: time.Unix(parseInt64(data["joinTime"]), 0),
 		Chatted:  parseInt64(data["chatted"]),
+		Tags:     splitTags(data["tags"]),
+		Client:   entity.ClientInfo{IP: data["ip"], DeviceID: data["device"]},
+		Shadow:   data["shadow"] == "1",
 	}
 
 	return user, nil
 }
 
-// RemoveUser removes a user from Redis and the waiting queue
+// RemoveUser removes a user from Redis and the waiting queues
 func (r *UserRepository) RemoveUser(ctx context.Context, userID string) error {
-	userKey := fmt.Sprintf("user:%s", userID)
-
-	// Remove user from Redis Hash
-	err := r.client.Del(ctx, userKey).Err()
-	if err != nil {
+	// Remove user from waiting queues before the hash holding their tags is gone
+	if err := r.RemoveFromQueue(ctx, userID); err != nil {
 		return err
 	}
 
-	// Remove user from waiting queue (Sorted Set)
-	err = r.client.ZRem(ctx, r.queue, userID).Err()
-	return err
+	// Remove user from Redis Hash
+	userKey := fmt.Sprintf("user:%s", userID)
+	return r.client.Del(ctx, userKey).Err()
 }
 
 // Helper function to parse int64
@@ -104,23 +190,34 @@ func parseInt64(s string) int64 {
 	return int64(val.Seconds())
 }
 
-// PopTopUsers retrieves and removes the top `limit` users from the queue
+// PopTopUsers atomically retrieves and removes the top `limit` users from the queue
 func (r *UserRepository) PopTopUsers(ctx context.Context, limit int) ([]entity.User, error) {
-	// Step 1️⃣: Get the top `limit` user IDs from the queue
-	userIDs, err := r.client.ZRange(ctx, r.queue, 0, int64(limit)-1).Result()
-	if err != nil || len(userIDs) == 0 {
-		log.Println("⚠️ No users found in queue")
+	// Step 1️⃣: Pop the top `limit` user IDs in a single command
+	entries, err := r.client.ZPopMin(ctx, r.queue, int64(limit)).Result()
+	if err != nil {
+		log.Printf("❌ Error popping users from queue: %v", err)
 		return nil, err
 	}
+	if len(entries) == 0 {
+		log.Println("⚠️ No users found in queue")
+		return nil, nil
+	}
+	userIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		userIDs[i], _ = entry.Member.(string)
+	}
 
-	// Step 2️⃣: Remove these users from the queue
-	r.client.ZRem(ctx, r.queue, userIDs)
+	// Popped users are no longer waiting on their tags either; a stale tag entry
+	// cannot be claimed anyway since claims check the waiting queue
+	if err := r.RemoveFromQueue(ctx, userIDs...); err != nil {
+		return nil, err
+	}
 
-	// Step 3️⃣: Retrieve full user data
+	// Step 2️⃣: Retrieve full user data
 	var users []entity.User
 	for _, userID := range userIDs {
 		user, err := r.GetUser(ctx, userID)
-		if err == nil {
+		if err == nil && user != nil {
 			users = append(users, *user)
 		} else {
 			log.Printf("⚠️ Could not retrieve user %s from Redis", userID)
@@ -130,6 +227,74 @@ func (r *UserRepository) PopTopUsers(ctx context.Context, limit int) ([]entity.U
 	return users, nil
 }
 
+// ClaimUsers atomically takes all given users out of the queues, or none of them
+// if any has already been claimed by another worker, and records the claim until ttl
+func (r *UserRepository) ClaimUsers(ctx context.Context, ttl time.Duration, userIDs ...string) (bool, error) {
+	tagQueues, err := r.tagQueues(ctx, userIDs...)
+	if err != nil {
+		log.Printf("❌ Error reading tags of users %v: %v", userIDs, err)
+		return false, err
+	}
+
+	args := make([]interface{}, 0, len(userIDs)+2)
+	args = append(args, claimMember(userIDs), time.Now().Add(ttl).UnixMilli())
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+
+	keys := append([]string{r.queue, r.claims()}, tagQueues...)
+	claimed, err := claimUsersScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		log.Printf("❌ Error claiming users %v: %v", userIDs, err)
+		return false, err
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseClaim clears the claim record of users whose pairing has finished
+func (r *UserRepository) ReleaseClaim(ctx context.Context, userIDs ...string) error {
+	if err := r.client.ZRem(ctx, r.claims(), claimMember(userIDs)).Err(); err != nil {
+		log.Printf("❌ Error releasing claim on users %v: %v", userIDs, err)
+		return err
+	}
+	return nil
+}
+
+// TakeExpiredClaims removes and returns the users of claims whose deadline has passed.
+// ZREM decides which caller takes a claim, so instances never recover the same users twice.
+func (r *UserRepository) TakeExpiredClaims(ctx context.Context, now time.Time) ([][]string, error) {
+	members, err := r.client.ZRangeByScore(ctx, r.claims(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		log.Printf("❌ Error reading expired claims: %v", err)
+		return nil, err
+	}
+
+	var claims [][]string
+	for _, member := range members {
+		removed, err := r.client.ZRem(ctx, r.claims(), member).Result()
+		if err != nil {
+			return claims, err
+		}
+		var userIDs []string
+		if removed == 0 || json.Unmarshal([]byte(member), &userIDs) != nil {
+			continue // Taken by another instance
+		}
+		claims = append(claims, userIDs)
+	}
+	return claims, nil
+}
+
+// claimMember identifies a claim by its users, whatever order they are given in
+func claimMember(userIDs []string) string {
+	sorted := append([]string(nil), userIDs...)
+	sort.Strings(sorted)
+	member, _ := json.Marshal(sorted)
+	return string(member)
+}
+
 // UpdateUserChatID updates the user's chat ID in Redis
 func (r *UserRepository) UpdateUserChatID(ctx context.Context, userID, chatID string) error {
 	userKey := fmt.Sprintf("user:%s", userID)
@@ -145,7 +310,6 @@ func (r *UserRepository) UpdateUserChatID(ctx context.Context, userID, chatID st
 	return nil
 }
 
-
 // GetQueueLength returns the number of users in the waiting queue
 func (r *UserRepository) GetQueueLength(ctx context.Context) (int, error) {
 	count, err := r.client.ZCard(ctx, r.queue).Result()
@@ -155,3 +319,136 @@ func (r *UserRepository) GetQueueLength(ctx context.Context) (int, error) {
 	}
 	return int(count), nil
 }
+
+// PeekQueue returns up to `limit` of the oldest waiting users without removing them
+func (r *UserRepository) PeekQueue(ctx context.Context, limit int) ([]entity.User, error) {
+	entries, err := r.client.ZRangeWithScores(ctx, r.queue, 0, int64(limit)-1).Result()
+	if err != nil {
+		log.Printf("❌ Error reading queue: %v", err)
+		return nil, err
+	}
+
+	users := make([]entity.User, 0, len(entries))
+	for _, entry := range entries {
+		userID, _ := entry.Member.(string)
+		user, err := r.GetUser(ctx, userID)
+		if err != nil || user == nil {
+			log.Printf("⚠️ Could not retrieve user %s from Redis", userID)
+			continue
+		}
+		user.QueuedAt = time.UnixMilli(int64(entry.Score))
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+// FindTagPartners returns up to `limit` users sharing an interest tag with `user`, longest-waiting first
+func (r *UserRepository) FindTagPartners(ctx context.Context, user entity.User, limit int) ([]entity.User, error) {
+	scores := make(map[string]float64)
+
+	for _, tag := range user.Tags {
+		// One extra entry covers the user itself
+		entries, err := r.client.ZRangeWithScores(ctx, r.tagQueue(tag), 0, int64(limit)).Result()
+		if err != nil {
+			log.Printf("❌ Error reading tag queue %s: %v", tag, err)
+			return nil, err
+		}
+		for _, entry := range entries {
+			memberID, _ := entry.Member.(string)
+			if memberID != user.UserID {
+				scores[memberID] = entry.Score
+			}
+		}
+	}
+
+	memberIDs := make([]string, 0, len(scores))
+	for memberID := range scores {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Slice(memberIDs, func(i, j int) bool { return scores[memberIDs[i]] < scores[memberIDs[j]] })
+	if len(memberIDs) > limit {
+		memberIDs = memberIDs[:limit]
+	}
+
+	partners := make([]entity.User, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		partner, err := r.GetUser(ctx, memberID)
+		if err != nil || partner == nil {
+			continue
+		}
+		partner.QueuedAt = time.UnixMilli(int64(scores[memberID]))
+		partners = append(partners, *partner)
+	}
+	return partners, nil
+}
+
+// RemoveFromQueue takes users out of the global waiting queue and their interest tag queues
+func (r *UserRepository) RemoveFromQueue(ctx context.Context, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	tagQueues, err := r.tagQueues(ctx, userIDs...)
+	if err != nil {
+		return err
+	}
+
+	members := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = userID
+	}
+
+	pipe := r.client.TxPipeline()
+	for _, key := range append(tagQueues, r.queue) {
+		pipe.ZRem(ctx, key, members...)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("❌ Error removing users from queue: %v", err)
+		return err
+	}
+	return nil
+}
+
+// queueEvents returns the pub/sub channel announcing enqueued users
+func (r *UserRepository) queueEvents() string {
+	return fmt.Sprintf("%s:events", r.queue)
+}
+
+// claims returns the sorted set of claimed users, scored by when each claim expires
+func (r *UserRepository) claims() string {
+	return fmt.Sprintf("%s:claims", r.queue)
+}
+
+// tagQueue returns the sorted set key holding users waiting on an interest tag
+func (r *UserRepository) tagQueue(tag string) string {
+	return fmt.Sprintf("%s:tag:%s", r.queue, tag)
+}
+
+// tagQueues returns the distinct tag queue keys the given users may be waiting in
+func (r *UserRepository) tagQueues(ctx context.Context, userIDs ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, userID := range userIDs {
+		tags, err := r.client.HGet(ctx, fmt.Sprintf("user:%s", userID), "tags").Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for _, tag := range splitTags(tags) {
+			if key := r.tagQueue(tag); !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// Helper function to split the stored comma-separated tag list
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,387 @@
+package infrastructure_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/persistence"
+)
+
+// backend opens fresh repositories for one storage implementation.
+// advance moves the backend's clock forward for TTL checks.
+type backend struct {
+	name string
+	open func(t *testing.T) (users repository.UserRepository, chats repository.ChatRepository, advance func(time.Duration))
+}
+
+var backends = []backend{
+	{"memory", func(t *testing.T) (repository.UserRepository, repository.ChatRepository, func(time.Duration)) {
+		return memory.NewUserRepository(), memory.NewChatRepository(), time.Sleep
+	}},
+	{"redis", func(t *testing.T) (repository.UserRepository, repository.ChatRepository, func(time.Duration)) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		// miniredis only expires keys when told that time has passed
+		return persistence.NewUserRepository(client, "waiting_queue"), persistence.NewChatRepository(client), server.FastForward
+	}},
+}
+
+func enqueue(t *testing.T, users repository.UserRepository, userID string, tags ...string) {
+	t.Helper()
+	if err := users.AddUserToQueue(context.Background(), entity.User{UserID: userID, JoinTime: time.Now(), Tags: tags}); err != nil {
+		t.Fatalf("enqueue %s: %v", userID, err)
+	}
+}
+
+func ids(users []entity.User) []string {
+	out := make([]string, len(users))
+	for i, user := range users {
+		out[i] = user.UserID
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, users repository.UserRepository, advance func(time.Duration))
+	}{
+		{"queue orders by score then user ID", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			enqueue(t, users, "late-first")
+			time.Sleep(5 * time.Millisecond)
+			for _, userID := range []string{"e", "d", "c", "b", "a"} {
+				enqueue(t, users, userID)
+			}
+
+			queued, err := users.PeekQueue(ctx, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) != 6 || queued[0].UserID != "late-first" {
+				t.Fatalf("queue = %v, want late-first first of 6", ids(queued))
+			}
+			ordered := sort.SliceIsSorted(queued, func(i, j int) bool {
+				if !queued[i].QueuedAt.Equal(queued[j].QueuedAt) {
+					return queued[i].QueuedAt.Before(queued[j].QueuedAt)
+				}
+				return queued[i].UserID < queued[j].UserID
+			})
+			if !ordered {
+				t.Errorf("queue %v is not ordered by score then user ID", ids(queued))
+			}
+			if length, _ := users.GetQueueLength(ctx); length != 6 {
+				t.Errorf("queue length = %d, want 6", length)
+			}
+		}},
+		{"claim is all or nothing", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			enqueue(t, users, "a", "go")
+			enqueue(t, users, "b", "go")
+			enqueue(t, users, "c")
+
+			if claimed, err := users.ClaimUsers(ctx, time.Minute, "a", "b"); err != nil || !claimed {
+				t.Fatalf("claim a, b = %v, %v; want true", claimed, err)
+			}
+			if claimed, err := users.ClaimUsers(ctx, time.Minute, "b", "c"); err != nil || claimed {
+				t.Fatalf("claim b, c = %v, %v; want false", claimed, err)
+			}
+			if claimed, _ := users.ClaimUsers(ctx, time.Minute, "c", "unknown"); claimed {
+				t.Fatal("claim with an unknown user succeeded")
+			}
+
+			queued, _ := users.PeekQueue(ctx, 10)
+			if !equal(ids(queued), []string{"c"}) {
+				t.Errorf("queue after claims = %v, want [c]", ids(queued))
+			}
+			// Claimed users leave their tag queues too
+			if partners, _ := users.FindTagPartners(ctx, entity.User{UserID: "x", Tags: []string{"go"}}, 5); len(partners) != 0 {
+				t.Errorf("tag partners after claim = %v, want none", ids(partners))
+			}
+		}},
+		{"expired claims are taken once", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			for _, userID := range []string{"a", "b", "c", "d"} {
+				enqueue(t, users, userID)
+			}
+			users.ClaimUsers(ctx, time.Minute, "a", "b")
+			users.ClaimUsers(ctx, time.Minute, "d", "c")
+
+			if claims, _ := users.TakeExpiredClaims(ctx, time.Now()); len(claims) != 0 {
+				t.Fatalf("claims taken before expiry: %v", claims)
+			}
+			// Releasing works whatever order the users are given in
+			if err := users.ReleaseClaim(ctx, "b", "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			later := time.Now().Add(2 * time.Minute)
+			claims, err := users.TakeExpiredClaims(ctx, later)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(claims) != 1 {
+				t.Fatalf("expired claims = %v, want only c and d", claims)
+			}
+			taken := append([]string(nil), claims[0]...)
+			sort.Strings(taken)
+			if !equal(taken, []string{"c", "d"}) {
+				t.Errorf("expired claim = %v, want [c d]", taken)
+			}
+			if claims, _ := users.TakeExpiredClaims(ctx, later); len(claims) != 0 {
+				t.Errorf("claims taken twice: %v", claims)
+			}
+		}},
+		{"pop takes the oldest users", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			for _, userID := range []string{"x", "y", "z"} {
+				enqueue(t, users, userID)
+				time.Sleep(2 * time.Millisecond)
+			}
+
+			popped, err := users.PopTopUsers(ctx, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(ids(popped), []string{"x", "y"}) {
+				t.Errorf("popped %v, want [x y]", ids(popped))
+			}
+			queued, _ := users.PeekQueue(ctx, 10)
+			if !equal(ids(queued), []string{"z"}) {
+				t.Errorf("queue after pop = %v, want [z]", ids(queued))
+			}
+			if popped, _ := users.PopTopUsers(ctx, 5); !equal(ids(popped), []string{"z"}) {
+				t.Errorf("second pop = %v, want [z]", ids(popped))
+			}
+			if popped, _ := users.PopTopUsers(ctx, 5); len(popped) != 0 {
+				t.Errorf("pop of an empty queue = %v", ids(popped))
+			}
+		}},
+		{"tag partners share a tag, oldest first", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			enqueue(t, users, "a", "go")
+			time.Sleep(2 * time.Millisecond)
+			enqueue(t, users, "b", "rust")
+			time.Sleep(2 * time.Millisecond)
+			enqueue(t, users, "c", "go", "rust")
+			time.Sleep(2 * time.Millisecond)
+			enqueue(t, users, "d")
+
+			me := entity.User{UserID: "c", Tags: []string{"go", "rust"}}
+			partners, err := users.FindTagPartners(ctx, me, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(ids(partners), []string{"a", "b"}) {
+				t.Errorf("partners of c = %v, want [a b]", ids(partners))
+			}
+			if partners, _ := users.FindTagPartners(ctx, me, 1); !equal(ids(partners), []string{"a"}) {
+				t.Errorf("partners of c with limit 1 = %v, want [a]", ids(partners))
+			}
+			if partners[0].QueuedAt.IsZero() {
+				t.Error("tag partners carry no queue time")
+			}
+
+			if err := users.RemoveFromQueue(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			if partners, _ := users.FindTagPartners(ctx, me, 5); !equal(ids(partners), []string{"b"}) {
+				t.Errorf("partners of c after a left = %v, want [b]", ids(partners))
+			}
+		}},
+		{"skips expire", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			if err := users.MarkSkipped(ctx, "a", "b", 50*time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			for _, pair := range [][2]string{{"a", "b"}, {"b", "a"}} {
+				if skipped, err := users.IsSkipped(ctx, pair[0], pair[1]); err != nil || !skipped {
+					t.Errorf("IsSkipped(%s, %s) = %v, %v; want true", pair[0], pair[1], skipped, err)
+				}
+			}
+			if skipped, _ := users.IsSkipped(ctx, "a", "c"); skipped {
+				t.Error("an unrelated pair is skipped")
+			}
+
+			advance(60 * time.Millisecond)
+			if skipped, _ := users.IsSkipped(ctx, "a", "b"); skipped {
+				t.Error("skip did not expire")
+			}
+		}},
+		{"subscribers wake on enqueue", func(t *testing.T, users repository.UserRepository, advance func(time.Duration)) {
+			subCtx, cancel := context.WithCancel(ctx)
+			wake := users.SubscribeToQueue(subCtx)
+
+			// A subscription may still be starting; keep enqueueing until it fires
+			deadline := time.After(2 * time.Second)
+			tick := time.NewTicker(20 * time.Millisecond)
+			defer tick.Stop()
+		wait:
+			for n := 0; ; n++ {
+				enqueue(t, users, "waker-"+string(rune('a'+n%26)))
+				select {
+				case <-wake:
+					break wait
+				case <-tick.C:
+				case <-deadline:
+					t.Fatal("no wake-up after enqueueing")
+				}
+			}
+
+			cancel()
+			select {
+			case _, ok := <-drain(wake):
+				if ok {
+					t.Error("subscription still open after cancel")
+				}
+			case <-time.After(2 * time.Second):
+				t.Error("subscription not closed after cancel")
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, b := range backends {
+				t.Run(b.name, func(t *testing.T) {
+					users, _, advance := b.open(t)
+					test.run(t, users, advance)
+				})
+			}
+		})
+	}
+}
+
+// drain discards pending wake-ups and reports once the channel is closed
+func drain(wake <-chan struct{}) <-chan struct{} {
+	closed := make(chan struct{})
+	go func() {
+		for range wake {
+		}
+		close(closed)
+	}()
+	return closed
+}
+
+func newChat(chatID, userA, userB string) *entity.Chat {
+	return &entity.Chat{
+		ID:        chatID,
+		UserA:     entity.User{UserID: userA},
+		UserB:     entity.User{UserID: userB},
+		StartTime: time.Now(),
+	}
+}
+
+func TestChatRepositoryContract(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, chats repository.ChatRepository)
+	}{
+		{"saved chats are read back with partners", func(t *testing.T, chats repository.ChatRepository) {
+			chat := newChat("chat-1", "a", "b")
+			if err := chats.SaveChatSession(ctx, chat); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := chats.GetChatSession(ctx, chat.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.UserA.UserID != "a" || stored.UserB.UserID != "b" {
+				t.Errorf("stored chat has users %s, %s", stored.UserA.UserID, stored.UserB.UserID)
+			}
+			if partner, _ := chats.GetChatPartner(ctx, chat.ID, "b"); partner == nil || partner.UserID != "a" {
+				t.Errorf("partner of b = %v, want a", partner)
+			}
+			if _, err := chats.GetChatSession(ctx, "missing"); err == nil {
+				t.Error("reading a missing chat did not fail")
+			}
+		}},
+		{"message counts stop once the chat ends", func(t *testing.T, chats repository.ChatRepository) {
+			chat := newChat("chat-1", "a", "b")
+			if err := chats.SaveChatSession(ctx, chat); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				if err := chats.IncrementMessageCount(ctx, chat.ID, "a"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if stored, _ := chats.GetChatSession(ctx, chat.ID); stored.MessageCounts["a"] != 3 || stored.MessageCounts["b"] != 0 {
+				t.Errorf("message counts = %v, want a:3", stored.MessageCounts)
+			}
+
+			if deleted, err := chats.DeleteChatSession(ctx, chat.ID); err != nil || !deleted {
+				t.Fatalf("delete = %v, %v; want true", deleted, err)
+			}
+			if err := chats.IncrementMessageCount(ctx, chat.ID, "a"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := chats.GetChatSession(ctx, chat.ID); err == nil {
+				t.Error("counting a message recreated an ended chat")
+			}
+		}},
+		{"only one of racing deletes removes the chat", func(t *testing.T, chats repository.ChatRepository) {
+			chat := newChat("chat-1", "a", "b")
+			if err := chats.SaveChatSession(ctx, chat); err != nil {
+				t.Fatal(err)
+			}
+
+			results := make(chan bool, 8)
+			var wg sync.WaitGroup
+			for i := 0; i < cap(results); i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					deleted, err := chats.DeleteChatSession(ctx, chat.ID)
+					if err != nil {
+						t.Error(err)
+					}
+					results <- deleted
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			winners := 0
+			for deleted := range results {
+				if deleted {
+					winners++
+				}
+			}
+			if winners != 1 {
+				t.Errorf("%d deletes reported removing the chat, want 1", winners)
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, b := range backends {
+				t.Run(b.name, func(t *testing.T) {
+					_, chats, _ := b.open(t)
+					test.run(t, chats)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package web_socket_hub
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// OverflowPolicy decides what happens when a client's send buffer is full
+type OverflowPolicy string
+
+const (
+	OverflowDrop       OverflowPolicy = "drop"       // Discard the message and keep the client
+	OverflowDisconnect OverflowPolicy = "disconnect" // Close the slow client's connection
+	OverflowBlock      OverflowPolicy = "block"      // Wait up to BlockTimeout for room, then discard
+)
+
+// flushTimeout bounds how long a closing client spends writing what is still queued
+const flushTimeout = time.Second
+
+// Errors returned when a message cannot be queued for a client
+var (
+	ErrClientClosed   = errors.New("client connection closed")
+	ErrSendBufferFull = errors.New("client send buffer full")
+)
+
+// ClientConfig controls the outbound queue of every client
+type ClientConfig struct {
+	SendBuffer   int            // Messages queued per client before the overflow policy applies
+	Overflow     OverflowPolicy // What to do when the queue is full
+	BlockTimeout time.Duration  // How long OverflowBlock waits for room
+	PingInterval time.Duration  // How often the server pings clients whose transport supports it
+}
+
+// DefaultClientConfig is used for any zero value in a ClientConfig
+var DefaultClientConfig = ClientConfig{
+	SendBuffer:   64,
+	Overflow:     OverflowDrop,
+	BlockTimeout: time.Second,
+	PingInterval: 30 * time.Second,
+}
+
+// pinger is implemented by connections whose transport has heartbeats
+type pinger interface {
+	Ping() error
+}
+
+// withDefaults fills unset fields from DefaultClientConfig
+func (c ClientConfig) withDefaults() ClientConfig {
+	if c.SendBuffer <= 0 {
+		c.SendBuffer = DefaultClientConfig.SendBuffer
+	}
+	switch c.Overflow {
+	case OverflowDrop, OverflowDisconnect, OverflowBlock:
+	default:
+		c.Overflow = DefaultClientConfig.Overflow
+	}
+	if c.BlockTimeout <= 0 {
+		c.BlockTimeout = DefaultClientConfig.BlockTimeout
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = DefaultClientConfig.PingInterval
+	}
+	return c
+}
+
+// Client wraps a connection with its own bounded outbound queue.
+// A dedicated write pump is the only goroutine that writes to the connection,
+// so a slow client never blocks senders or the hub. The pump also pings
+// clients whose transport supports it; a client that stops answering is
+// marked lost and disconnected.
+type Client struct {
+	userID    string
+	conn      repository.Connection
+	config    ClientConfig
+	send      chan *entity.Message
+	done      chan struct{}
+	closeOnce sync.Once
+	lost      atomic.Bool // Set when the heartbeat failed rather than the client closing cleanly
+}
+
+// newClient wraps conn and starts its write pump
+func newClient(userID string, conn repository.Connection, config ClientConfig) *Client {
+	c := &Client{
+		userID: userID,
+		conn:   conn,
+		config: config,
+		send:   make(chan *entity.Message, config.SendBuffer),
+		done:   make(chan struct{}),
+	}
+
+	go c.writePump()
+	return c
+}
+
+// MarkLost records that the client vanished without closing the connection
+func (c *Client) MarkLost() {
+	c.lost.Store(true)
+}
+
+// Lost reports whether the client's heartbeat failed
+func (c *Client) Lost() bool {
+	return c.lost.Load()
+}
+
+// Enqueue queues a message for the write pump, applying the overflow policy when the queue is full
+func (c *Client) Enqueue(message *entity.Message) error {
+	select {
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+
+	select {
+	case c.send <- message:
+		return nil
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+
+	switch c.config.Overflow {
+	case OverflowDisconnect:
+		log.Printf("⚠️ Send buffer full for %s, disconnecting slow client", c.userID)
+		c.Close()
+		return ErrSendBufferFull
+	case OverflowBlock:
+		timer := time.NewTimer(c.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case c.send <- message:
+			return nil
+		case <-c.done:
+			return ErrClientClosed
+		case <-timer.C:
+			return ErrSendBufferFull
+		}
+	default:
+		return ErrSendBufferFull
+	}
+}
+
+// Close stops the write pump and closes the connection. It is safe to call more than once.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// writePump writes queued messages until the client is closed or a write fails
+func (c *Client) writePump() {
+	defer c.conn.Close()
+
+	// Transports without heartbeats get a nil channel, which never fires
+	var heartbeat <-chan time.Time
+	pinger, canPing := c.conn.(pinger)
+	if canPing {
+		ticker := time.NewTicker(c.config.PingInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.done:
+			c.flush()
+			return
+		case message := <-c.send:
+			if err := c.conn.WriteMessage(message); err != nil {
+				log.Printf("⚠️ Error writing to %s: %v", c.userID, err)
+				c.MarkLost()
+				c.Close()
+				return
+			}
+		case <-heartbeat:
+			if err := pinger.Ping(); err != nil {
+				log.Printf("💔 Heartbeat failed for %s: %v", c.userID, err)
+				c.MarkLost()
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// flush makes a best-effort attempt to write messages still queued when the client closes
+func (c *Client) flush() {
+	deadline := time.Now().Add(flushTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case message := <-c.send:
+			if err := c.conn.WriteMessage(message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package web_socket_hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+const (
+	nodeTTL           = 30 * time.Second // A node is considered gone once its heartbeat key expires
+	heartbeatInterval = 10 * time.Second // How often a node refreshes its heartbeat key
+	routeTimeout      = 2 * time.Second  // Upper bound for a single routing lookup or publish
+)
+
+// releaseConnScript deletes a connection route only if it still points at this node,
+// so a user who already reconnected elsewhere keeps their new route.
+//
+// KEYS[1] = connection route, ARGV[1] = node ID
+var releaseConnScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// routedMessage is published to a node's channel for one of its local users.
+// It carries either a message to deliver or a request to close the connection.
+type routedMessage struct {
+	UserID  string          `json:"user_id"`
+	Message *entity.Message `json:"message,omitempty"`
+	Close   bool            `json:"close,omitempty"`
+}
+
+// ClusterHub routes messages across server instances. Each node records the users it
+// owns in Redis; messages for a user on another node are published to that node's
+// channel and delivered by its local hub.
+type ClusterHub struct {
+	local    *WebSocketHub
+	client   *redis.Client
+	nodeID   string
+	stopChan chan struct{}
+}
+
+// Ensure ClusterHub implements WebSocketRepository
+var _ repository.WebSocketRepository = &ClusterHub{}
+
+// NewClusterHub wraps a local hub with Redis-backed routing for node `nodeID`
+func NewClusterHub(local *WebSocketHub, client *redis.Client, nodeID string) *ClusterHub {
+	return &ClusterHub{
+		local:    local,
+		client:   client,
+		nodeID:   nodeID,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// NodeID returns the ID this node routes under
+func (h *ClusterHub) NodeID() string {
+	return h.nodeID
+}
+
+// Run announces this node and delivers messages routed to it until Shutdown
+func (h *ClusterHub) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := h.client.Subscribe(ctx, nodeChannel(h.nodeID))
+	defer sub.Close()
+	messages := sub.Channel()
+
+	h.heartbeat(ctx)
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	log.Printf("🌐 Cluster routing started for node %s", h.nodeID)
+
+	for {
+		select {
+		case <-h.stopChan:
+			return
+		case <-ticker.C:
+			h.heartbeat(ctx)
+		case msg, ok := <-messages:
+			if !ok {
+				log.Println("⚠️ Cluster routing subscription closed")
+				return
+			}
+			h.deliver(msg.Payload)
+		}
+	}
+}
+
+// heartbeat refreshes this node's liveness key
+func (h *ClusterHub) heartbeat(ctx context.Context) {
+	if err := h.client.Set(ctx, nodeKey(h.nodeID), time.Now().Unix(), nodeTTL).Err(); err != nil {
+		log.Printf("❌ Failed to refresh heartbeat for node %s: %v", h.nodeID, err)
+	}
+}
+
+// deliver hands a routed message to the local hub, or closes the connection it names
+func (h *ClusterHub) deliver(payload string) {
+	var routed routedMessage
+	if err := json.Unmarshal([]byte(payload), &routed); err != nil {
+		log.Printf("⚠️ Dropping malformed routed message: %v", err)
+		return
+	}
+	if routed.Close {
+		// Never forwarded again: a user who moved on since keeps their new connection
+		h.local.RemoveConnection(routed.UserID)
+		h.releaseRoute(routed.UserID)
+		return
+	}
+	if err := h.local.SendMessage(routed.UserID, routed.Message); err != nil {
+		log.Printf("⚠️ Failed to deliver routed message to %s: %v", routed.UserID, err)
+	}
+}
+
+// AddConnection stores a client connection and claims the user for this node
+func (h *ClusterHub) AddConnection(userID string, conn repository.Connection) {
+	h.local.AddConnection(userID, conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), routeTimeout)
+	defer cancel()
+	if err := h.client.Set(ctx, connKey(userID), h.nodeID, 0).Err(); err != nil {
+		log.Printf("❌ Failed to record route for %s: %v", userID, err)
+	}
+}
+
+// RemoveConnection removes a connection and releases the user's route. A user connected
+// to another node has their connection closed there, e.g. when a ban ends their session.
+func (h *ClusterHub) RemoveConnection(userID string) {
+	if h.local.HasConnection(userID) {
+		h.local.RemoveConnection(userID)
+		h.releaseRoute(userID)
+		return
+	}
+	if reason, err := h.forward(routedMessage{UserID: userID, Close: true}); err != nil && reason != metrics.SendFailureNotConnected {
+		log.Printf("⚠️ Not closing connection of %s on another node: %v", userID, err)
+	}
+}
+
+// GetConnection retrieves a client connection owned by this node
+func (h *ClusterHub) GetConnection(userID string) repository.Connection {
+	return h.local.GetConnection(userID)
+}
+
+// ReadMessage reads from a connection owned by this node
+func (h *ClusterHub) ReadMessage(userID string) (*entity.Message, error) {
+	return h.local.ReadMessage(userID)
+}
+
+// SendMessage delivers locally when possible, otherwise publishes to the owning node
+func (h *ClusterHub) SendMessage(userID string, message *entity.Message) error {
+	if h.local.HasConnection(userID) {
+		return h.local.SendMessage(userID, message)
+	}
+
+	if reason, err := h.forward(routedMessage{UserID: userID, Message: message}); err != nil {
+		metrics.SendFailures.WithLabelValues(reason).Inc()
+		return err
+	}
+	return nil
+}
+
+// forward publishes a routed message to the live node that owns its user.
+// On failure it also returns the send failure reason for metrics.
+func (h *ClusterHub) forward(routed routedMessage) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), routeTimeout)
+	defer cancel()
+
+	userID := routed.UserID
+	nodeID, err := h.client.Get(ctx, connKey(userID)).Result()
+	if err == redis.Nil || nodeID == h.nodeID {
+		return metrics.SendFailureNotConnected, fmt.Errorf("user %s not connected", userID)
+	}
+	if err != nil {
+		return metrics.SendFailureRoute, fmt.Errorf("error looking up route for %s: %v", userID, err)
+	}
+
+	alive, err := h.client.Exists(ctx, nodeKey(nodeID)).Result()
+	if err != nil {
+		return metrics.SendFailureRoute, fmt.Errorf("error checking node %s: %v", nodeID, err)
+	}
+	if alive == 0 {
+		return metrics.SendFailureNotConnected, fmt.Errorf("user %s not connected (node %s is gone)", userID, nodeID)
+	}
+
+	payload, err := json.Marshal(routed)
+	if err != nil {
+		return metrics.SendFailureEncode, fmt.Errorf("error encoding routed message for %s: %v", userID, err)
+	}
+	if err := h.client.Publish(ctx, nodeChannel(nodeID), payload).Err(); err != nil {
+		return metrics.SendFailureRoute, fmt.Errorf("error routing message to %s via node %s: %v", userID, nodeID, err)
+	}
+	return "", nil
+}
+
+// Shutdown stops routing, releases every route owned by this node and closes local connections
+func (h *ClusterHub) Shutdown() {
+	close(h.stopChan)
+
+	for _, userID := range h.local.ConnectedUsers() {
+		h.releaseRoute(userID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), routeTimeout)
+	defer cancel()
+	if err := h.client.Del(ctx, nodeKey(h.nodeID)).Err(); err != nil {
+		log.Printf("⚠️ Failed to remove heartbeat for node %s: %v", h.nodeID, err)
+	}
+
+	h.local.Shutdown()
+}
+
+// releaseRoute deletes the user's route if this node still owns it
+func (h *ClusterHub) releaseRoute(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), routeTimeout)
+	defer cancel()
+	if err := releaseConnScript.Run(ctx, h.client, []string{connKey(userID)}, h.nodeID).Err(); err != nil {
+		log.Printf("⚠️ Failed to release route for %s: %v", userID, err)
+	}
+}
+
+// connKey returns the key naming the node that owns a user's connection
+func connKey(userID string) string {
+	return fmt.Sprintf("conn:%s", userID)
+}
+
+// nodeKey returns a node's heartbeat key
+func nodeKey(nodeID string) string {
+	return fmt.Sprintf("node:%s", nodeID)
+}
+
+// nodeChannel returns the pub/sub channel a node receives routed messages on
+func nodeChannel(nodeID string) string {
+	return fmt.Sprintf("node:%s:messages", nodeID)
+}
@@ -0,0 +1,89 @@
+package web_socket_hub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// idleConnection is a connection that never sends and records when it is closed
+type idleConnection struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newIdleConnection() *idleConnection {
+	return &idleConnection{closed: make(chan struct{})}
+}
+
+func (c *idleConnection) ReadMessage() (*entity.Message, error) {
+	<-c.closed
+	return nil, repository.ErrClosedByClient
+}
+
+func (c *idleConnection) WriteMessage(message *entity.Message) error {
+	return nil
+}
+
+func (c *idleConnection) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *idleConnection) RemoteAddr() string {
+	return "idle"
+}
+
+// startNode runs a cluster hub for nodeID and waits until it receives routed messages
+func startNode(t *testing.T, server *miniredis.Miniredis, nodeID string) *ClusterHub {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	hub := NewClusterHub(NewWebSocketHub(ClientConfig{}), client, nodeID)
+	go hub.Run()
+	t.Cleanup(func() {
+		hub.Shutdown()
+		client.Close()
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.PubSubNumSub(nodeChannel(nodeID))[nodeChannel(nodeID)] == 0 || !server.Exists(nodeKey(nodeID)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("node %s did not start routing", nodeID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return hub
+}
+
+func TestRemoveConnectionClosesItOnTheOwningNode(t *testing.T) {
+	server := miniredis.RunT(t)
+	nodeA := startNode(t, server, "node-a")
+	nodeB := startNode(t, server, "node-b")
+
+	conn := newIdleConnection()
+	nodeB.AddConnection("user-1", conn)
+
+	// Node A ends the session, e.g. because a moderator banned the user there
+	nodeA.RemoveConnection("user-1")
+
+	select {
+	case <-conn.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection on the owning node was not closed")
+	}
+	if nodeB.local.HasConnection("user-1") {
+		t.Error("owning node still holds the connection")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for server.Exists(connKey("user-1")) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if server.Exists(connKey("user-1")) {
+		t.Error("route of the closed connection was kept")
+	}
+}
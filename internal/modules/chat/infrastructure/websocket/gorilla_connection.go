@@ -0,0 +1,107 @@
+package web_socket_hub
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// ConnectionConfig controls the deadlines of a gorilla WebSocket connection
+type ConnectionConfig struct {
+	PongWait  time.Duration // How long to wait for any frame or pong before the client is lost
+	WriteWait time.Duration // Deadline for every single write
+}
+
+// DefaultConnectionConfig is used for any zero value in a ConnectionConfig
+var DefaultConnectionConfig = ConnectionConfig{
+	PongWait:  60 * time.Second,
+	WriteWait: 10 * time.Second,
+}
+
+// withDefaults fills unset fields from DefaultConnectionConfig
+func (c ConnectionConfig) withDefaults() ConnectionConfig {
+	if c.PongWait <= 0 {
+		c.PongWait = DefaultConnectionConfig.PongWait
+	}
+	if c.WriteWait <= 0 {
+		c.WriteWait = DefaultConnectionConfig.WriteWait
+	}
+	return c
+}
+
+// GorillaConnection adapts a gorilla/websocket connection to repository.Connection.
+// It encodes messages for the negotiated subprotocol and keeps the read deadline
+// moving while the client sends frames or answers pings.
+type GorillaConnection struct {
+	conn   *websocket.Conn
+	config ConnectionConfig
+}
+
+// Ensure GorillaConnection implements Connection
+var _ repository.Connection = &GorillaConnection{}
+
+// NewGorillaConnection wraps an upgraded connection and arms its read deadline
+func NewGorillaConnection(conn *websocket.Conn, config ConnectionConfig) *GorillaConnection {
+	config = config.withDefaults()
+
+	// Any pong extends the read deadline; a silent client times out the reader
+	conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	})
+
+	return &GorillaConnection{conn: conn, config: config}
+}
+
+// ReadMessage blocks until the client sends a frame and decodes it into a message
+func (c *GorillaConnection) ReadMessage() (*entity.Message, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, fmt.Errorf("%w: %v", repository.ErrConnectionLost, err)
+		}
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			return nil, fmt.Errorf("%w: %v", repository.ErrClosedByClient, err)
+		}
+		return nil, err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	return decodeMessage(c.conn.Subprotocol(), data)
+}
+
+// WriteMessage encodes a message for the negotiated subprotocol and writes it
+func (c *GorillaConnection) WriteMessage(message *entity.Message) error {
+	data, err := encodeMessage(c.conn.Subprotocol(), message)
+	if err != nil {
+		return fmt.Errorf("error encoding message: %v", err)
+	}
+	if data == nil {
+		return nil // Nothing to send in this subprotocol
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Ping sends a heartbeat the client must answer with a pong
+func (c *GorillaConnection) Ping() error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+	return c.conn.WriteMessage(websocket.PingMessage, nil)
+}
+
+// Close closes the underlying network connection
+func (c *GorillaConnection) Close() error {
+	return c.conn.Close()
+}
+
+// RemoteAddr returns the client's network address
+func (c *GorillaConnection) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
@@ -0,0 +1,62 @@
+package web_socket_hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// Subprotocols negotiated on the WebSocket upgrade
+const (
+	JSONSubprotocol = "letsgo.v1.json" // Versioned JSON envelopes, only when negotiated
+	TextSubprotocol = "letsgo.text"    // Legacy raw text, also used when nothing is negotiated
+)
+
+// Commands raw-text clients send in place of JSON envelopes
+const (
+	textSkipCommand  = "/next"   // Skip the partner
+	textSolveCommand = "/solve " // Followed by the solution to the challenge
+)
+
+// Subprotocols lists every subprotocol the server accepts, in order of preference
+var Subprotocols = []string{JSONSubprotocol, TextSubprotocol}
+
+// encodeMessage renders a message for the negotiated subprotocol.
+// It returns nil when the message has no representation in that protocol.
+func encodeMessage(subprotocol string, msg *entity.Message) ([]byte, error) {
+	if rawText(subprotocol) {
+		if msg.Text == "" {
+			return nil, nil // e.g. typing indicators
+		}
+		return []byte(msg.Text), nil
+	}
+	return json.Marshal(msg)
+}
+
+// rawText reports whether a connection speaks raw text. Clients that predate the
+// JSON envelopes negotiate nothing, so only an explicit JSON subprotocol gets JSON.
+func rawText(subprotocol string) bool {
+	return subprotocol != JSONSubprotocol
+}
+
+// decodeMessage parses a client frame according to the negotiated subprotocol
+func decodeMessage(subprotocol string, data []byte) (*entity.Message, error) {
+	if rawText(subprotocol) {
+		if string(data) == textSkipCommand {
+			return &entity.Message{Version: entity.MessageVersion, Type: entity.MessageTypeSkip}, nil
+		}
+		if solution, ok := strings.CutPrefix(string(data), textSolveCommand); ok {
+			return &entity.Message{Version: entity.MessageVersion, Type: entity.MessageTypeSolution, Text: solution}, nil
+		}
+		return &entity.Message{Version: entity.MessageVersion, Type: entity.MessageTypeChat, Text: string(data)}, nil
+	}
+
+	var msg entity.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrInvalidMessage, err)
+	}
+	return &msg, nil
+}
@@ -0,0 +1,52 @@
+package web_socket_hub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		subprotocol string
+		frame       string
+		wantType    entity.MessageType
+		wantText    string
+		wantErr     error
+	}{
+		{"nothing negotiated is raw text", "", "hello", entity.MessageTypeChat, "hello", nil},
+		{"nothing negotiated keeps JSON-looking text", "", `{"type":"skip"}`, entity.MessageTypeChat, `{"type":"skip"}`, nil},
+		{"raw text skip command", TextSubprotocol, "/next", entity.MessageTypeSkip, "", nil},
+		{"raw text solution", "", "/solve 42", entity.MessageTypeSolution, "42", nil},
+		{"JSON envelope", JSONSubprotocol, `{"type":"chat","text":"hi"}`, entity.MessageTypeChat, "hi", nil},
+		{"JSON rejects raw text", JSONSubprotocol, "hello", "", "", repository.ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := decodeMessage(tt.subprotocol, []byte(tt.frame))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if msg.Type != tt.wantType || msg.Text != tt.wantText {
+				t.Errorf("message = %s %q, want %s %q", msg.Type, msg.Text, tt.wantType, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestEncodeMessageWithoutSubprotocolIsRawText(t *testing.T) {
+	data, err := encodeMessage("", entity.NewMessage(entity.MessageTypeChat, "hi"))
+	if err != nil || string(data) != "hi" {
+		t.Errorf("encoded %q, %v; want raw text", data, err)
+	}
+	if data, _ := encodeMessage("", entity.NewMessage(entity.MessageTypeTyping, "")); data != nil {
+		t.Errorf("typing indicator encoded as %q, want nothing", data)
+	}
+}
@@ -0,0 +1,154 @@
+package web_socket_hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+)
+
+// sseInboundBuffer is how many posted messages may wait for the read loop
+const sseInboundBuffer = 16
+
+// ErrSSEClosed is returned when a connection's event stream has ended
+var ErrSSEClosed = errors.New("event stream closed")
+
+// SSEConnection adapts a Server-Sent Events stream plus HTTP POSTs to repository.Connection.
+// Server messages are written to the open `GET /events` response as JSON envelopes;
+// client messages arrive through Deliver from `POST /messages`.
+type SSEConnection struct {
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	ctx        context.Context // Ends when the client drops the stream
+	remoteAddr string
+	inbound    chan *entity.Message
+
+	mu        sync.Mutex // Serializes writes against Close
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Ensure SSEConnection implements Connection
+var _ repository.Connection = &SSEConnection{}
+
+// NewSSEConnection starts an event stream on the response of r
+func NewSSEConnection(w http.ResponseWriter, r *http.Request) (*SSEConnection, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer does not support streaming")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep Nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &SSEConnection{
+		w:          w,
+		flusher:    flusher,
+		ctx:        r.Context(),
+		remoteAddr: r.RemoteAddr,
+		inbound:    make(chan *entity.Message, sseInboundBuffer),
+		closed:     make(chan struct{}),
+	}, nil
+}
+
+// Deliver decodes a posted JSON envelope and hands it to the read loop
+func (c *SSEConnection) Deliver(ctx context.Context, data []byte) error {
+	message, err := decodeMessage(JSONSubprotocol, data)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.inbound <- message:
+		return nil
+	case <-c.closed:
+		return ErrSSEClosed
+	case <-c.ctx.Done():
+		return ErrSSEClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReadMessage blocks until the client posts a message or the stream ends
+func (c *SSEConnection) ReadMessage() (*entity.Message, error) {
+	select {
+	case message := <-c.inbound:
+		return message, nil
+	case <-c.closed:
+		return nil, ErrSSEClosed
+	case <-c.ctx.Done():
+		// EventSource gives no close handshake, so a dropped stream may come back with a resume token
+		return nil, fmt.Errorf("%w: %v", repository.ErrConnectionLost, c.ctx.Err())
+	}
+}
+
+// WriteMessage sends a message as a single SSE event
+func (c *SSEConnection) WriteMessage(message *entity.Message) error {
+	data, err := encodeMessage(JSONSubprotocol, message)
+	if err != nil {
+		return fmt.Errorf("error encoding message: %v", err)
+	}
+
+	var event strings.Builder
+	if message.ID != "" {
+		fmt.Fprintf(&event, "id: %s\n", message.ID)
+	}
+	fmt.Fprintf(&event, "data: %s\n\n", data)
+	return c.write(event.String())
+}
+
+// Ping sends an SSE comment so idle proxies keep the stream open
+func (c *SSEConnection) Ping() error {
+	return c.write(": ping\n\n")
+}
+
+// write flushes an event to the stream unless it has already ended
+func (c *SSEConnection) write(event string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.closed:
+		return ErrSSEClosed
+	default:
+	}
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := c.w.Write([]byte(event)); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+// Close ends the stream; no write reaches the response afterwards
+func (c *SSEConnection) Close() error {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		close(c.closed)
+		c.mu.Unlock()
+	})
+	return nil
+}
+
+// Done is closed once the stream has been closed
+func (c *SSEConnection) Done() <-chan struct{} {
+	return c.closed
+}
+
+// RemoteAddr returns the client's network address
+func (c *SSEConnection) RemoteAddr() string {
+	return c.remoteAddr
+}
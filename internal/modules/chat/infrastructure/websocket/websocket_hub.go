@@ -1,91 +1,151 @@
 package web_socket_hub
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 
-	"github.com/gorilla/websocket"
+	"github.com/royroki/LetsGo/internal/common/metrics"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 )
 
 // WebSocketHub manages WebSocket connections
 type WebSocketHub struct {
-	WSHub map[string]*websocket.Conn
-	mu    sync.Mutex // Protects concurrent access
+	WSHub  map[string]*Client
+	mu     sync.RWMutex // Protects the map only; I/O happens outside the lock
+	config ClientConfig
 }
 
 // Ensure WebSocketHub implements WebSocketRepository
 var _ repository.WebSocketRepository = &WebSocketHub{}
 
 // NewWebSocketHub initializes WebSocketHub
-func NewWebSocketHub() *WebSocketHub {
-	return &WebSocketHub{WSHub: make(map[string]*websocket.Conn)}
+func NewWebSocketHub(config ClientConfig) *WebSocketHub {
+	return &WebSocketHub{
+		WSHub:  make(map[string]*Client),
+		config: config.withDefaults(),
+	}
 }
 
-// AddConnection stores a WebSocket connection
-func (h *WebSocketHub) AddConnection(userID string, conn *websocket.Conn) {
+// AddConnection stores a client connection, replacing any previous one for the user
+func (h *WebSocketHub) AddConnection(userID string, conn repository.Connection) {
+	client := newClient(userID, conn, h.config)
+
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	h.WSHub[userID] = conn
+	previous := h.WSHub[userID]
+	h.WSHub[userID] = client
+	metrics.ActiveConnections.Set(float64(len(h.WSHub)))
+	h.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
 }
 
 // RemoveConnection removes a WebSocket connection
 func (h *WebSocketHub) RemoveConnection(userID string) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	client, exists := h.WSHub[userID]
+	delete(h.WSHub, userID) // Remove from hub
+	metrics.ActiveConnections.Set(float64(len(h.WSHub)))
+	h.mu.Unlock()
 
-	if conn, exists := h.WSHub[userID]; exists {
-		conn.Close()
-		delete(h.WSHub, userID) // Remove from hub
+	if exists {
+		client.Close()
 	}
 }
 
-// GetConnection retrieves a WebSocket connection
-func (h *WebSocketHub) GetConnection(userID string) *websocket.Conn {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	conn, exists := h.WSHub[userID]
-	if !exists || conn == nil {
+// GetConnection retrieves a client connection
+func (h *WebSocketHub) GetConnection(userID string) repository.Connection {
+	client := h.client(userID)
+	if client == nil {
 		log.Printf("⚠️ No active WebSocket connection for %s", userID)
 		return nil
 	}
-	return conn
+	return client.conn
 }
 
-// SendMessage sends a message to a connected user
-func (h *WebSocketHub) SendMessage(userID string, message []byte) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+// HasConnection reports whether the user is connected to this hub
+func (h *WebSocketHub) HasConnection(userID string) bool {
+	return h.client(userID) != nil
+}
+
+// ConnectedUsers returns the IDs of all users connected to this hub
+func (h *WebSocketHub) ConnectedUsers() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	userIDs := make([]string, 0, len(h.WSHub))
+	for userID := range h.WSHub {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
 
-	conn, exists := h.WSHub[userID]
-	if !exists {
+// SendMessage queues a message on the user's write pump
+func (h *WebSocketHub) SendMessage(userID string, message *entity.Message) error {
+	client := h.client(userID)
+	if client == nil {
+		metrics.SendFailures.WithLabelValues(metrics.SendFailureNotConnected).Inc()
 		return fmt.Errorf("user %s not connected", userID)
 	}
 
-	err := conn.WriteMessage(websocket.TextMessage, message)
-	if err != nil {
+	if err := client.Enqueue(message); err != nil {
+		reason := metrics.SendFailureClosed
+		if errors.Is(err, ErrSendBufferFull) {
+			reason = metrics.SendFailureBufferFull
+		}
+		metrics.SendFailures.WithLabelValues(reason).Inc()
 		return fmt.Errorf("error sending message to %s: %v", userID, err)
 	}
 	return nil
 }
 
+// ReadMessage blocks until the user sends a frame and decodes it into a message.
+// A missed heartbeat surfaces as repository.ErrConnectionLost and a deliberate
+// close by the client as repository.ErrClosedByClient.
+func (h *WebSocketHub) ReadMessage(userID string) (*entity.Message, error) {
+	client := h.client(userID)
+	if client == nil {
+		return nil, fmt.Errorf("user %s not connected", userID)
+	}
+
+	message, err := client.conn.ReadMessage()
+	if errors.Is(err, repository.ErrConnectionLost) {
+		client.MarkLost()
+		client.Close()
+		return nil, err
+	}
+	if err != nil && !errors.Is(err, repository.ErrInvalidMessage) && client.Lost() {
+		// The write pump gave up on the client and closed the connection under the reader
+		client.Close()
+		return nil, fmt.Errorf("%w: %v", repository.ErrConnectionLost, err)
+	}
+	return message, err
+}
+
 // Shutdown gracefully closes all WebSocket connections and clears the hub
 func (h *WebSocketHub) Shutdown() {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	log.Println("🔻 Closing all active WebSocket connections...")
 
-	for userID, conn := range h.WSHub {
-		err := conn.Close()
-		if err != nil {
-			log.Printf("⚠️ Error closing WebSocket for user %s: %v", userID, err)
-		}
-		delete(h.WSHub, userID)
+	h.mu.Lock()
+	clients := h.WSHub
+	h.WSHub = make(map[string]*Client)
+	metrics.ActiveConnections.Set(0)
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.Close()
 	}
 
 	log.Println("✅ WebSocketHub shutdown complete.")
 }
 
+// client looks up the user's client under the read lock
+func (h *WebSocketHub) client(userID string) *Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.WSHub[userID]
+}
@@ -2,83 +2,261 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
+	"github.com/royroki/LetsGo/internal/common/metrics"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
 	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 )
 
+// scanLimit caps how many queued users are considered per matchmaking pass
+const scanLimit = 50
+
+// tagCandidates caps how many tag partners are considered for one user
+const tagCandidates = 5
+
+// fallbackInterval is how often the queue is re-checked without a wake-up, so
+// tagged users fall back to FIFO on time and missed notifications are recovered.
+const fallbackInterval = time.Second
+
+// maxClaimConflicts stops a pass after this many claims in a row were lost to
+// other workers, leaving the rest of the queue to them until the next wake-up.
+const maxClaimConflicts = 3
+
+// claimTTL is how long a claimed pair may go without a chat before another pass
+// puts them back in the queue, covering a worker that dies mid-pairing
+const claimTTL = 30 * time.Second
+
 // MatchmakingWorker handles user pairing from the queue
 type MatchmakingWorker struct {
 	chatUsecase interfaces.ChatUseCase
 	userRepo    repository.UserRepository
+	tagWait     time.Duration // How long a tagged user waits for a tag match before falling back to FIFO
 	stopChan    chan struct{} // Stop signal channel
+
+	running  atomic.Bool
+	lastLoop atomic.Int64 // UnixNano of the latest loop or drain iteration
 }
 
 // NewMatchmakingWorker initializes a MatchmakingWorker
-func NewMatchmakingWorker(chatUsecase interfaces.ChatUseCase, userRepo repository.UserRepository) *MatchmakingWorker {
+func NewMatchmakingWorker(chatUsecase interfaces.ChatUseCase, userRepo repository.UserRepository, tagWait time.Duration) *MatchmakingWorker {
 	return &MatchmakingWorker{
 		chatUsecase: chatUsecase,
 		userRepo:    userRepo,
+		tagWait:     tagWait,
 		stopChan:    make(chan struct{}),
 	}
 }
 
-// Run starts the matchmaking loop
+// Run starts the matchmaking loop. It wakes whenever a user is enqueued on
+// any instance and pairs everyone it can before going back to sleep.
 func (w *MatchmakingWorker) Run() {
 	log.Println("🔄 Matchmaking Worker Started...")
+	w.running.Store(true)
+	defer w.running.Store(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wake := w.userRepo.SubscribeToQueue(ctx)
+	ticker := time.NewTicker(fallbackInterval)
+	defer ticker.Stop()
 
 	for {
+		w.touch()
+		if err := w.chatUsecase.RecoverStaleClaims(ctx); err != nil {
+			log.Printf("❌ Error recovering stale claims: %v", err)
+		}
+		w.drainQueue(ctx)
+
 		select {
 		case <-w.stopChan:
 			log.Println("🛑 Matchmaking Worker Stopped.")
-			return;
-			
+			return
+		case _, ok := <-wake:
+			if !ok {
+				log.Println("⚠️ Queue subscription closed, falling back to polling")
+				wake = nil // A nil channel blocks, leaving only the ticker
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// drainQueue pairs users until no compatible pair is left in the queue
+func (w *MatchmakingWorker) drainQueue(ctx context.Context) {
+	conflicts := 0
+	for {
+		select {
+		case <-w.stopChan:
+			return
 		default:
-			ctx := context.Background()
+		}
+		// A long drain under load is progress, not a stall
+		w.touch()
+
+		// Check if at least 2 users exist before looking for a pair
+		userCount, err := w.userRepo.GetQueueLength(ctx)
+		if err != nil {
+			log.Printf("❌ Error checking queue length: %v", err)
+			return
+		}
+		if userCount < 2 {
+			return
+		}
+
+		userA, userB, err := w.findPair(ctx)
+		if err != nil {
+			log.Printf("❌ Error retrieving users from queue: %v", err)
+			return
+		}
+		if userA == nil {
+			return
+		}
+
+		// Bans issued while users waited take effect here: blocked users leave
+		// the queue and shadow-banned users move pools, then we look again
+		readyA, err := w.chatUsecase.ScreenQueuedUser(ctx, *userA)
+		if err != nil {
+			log.Printf("❌ Error screening user %s: %v", userA.UserID, err)
+			return
+		}
+		readyB, err := w.chatUsecase.ScreenQueuedUser(ctx, *userB)
+		if err != nil {
+			log.Printf("❌ Error screening user %s: %v", userB.UserID, err)
+			return
+		}
+		if !readyA || !readyB {
+			continue
+		}
+
+		// Another worker may have taken either user since we looked at the queue
+		claimed, err := w.userRepo.ClaimUsers(ctx, claimTTL, userA.UserID, userB.UserID)
+		if err != nil {
+			log.Printf("❌ Error claiming users from queue: %v", err)
+			return
+		}
+		if !claimed {
+			log.Printf("⚠️ Users %s & %s already claimed, retrying...", userA.UserID, userB.UserID)
+			if conflicts++; conflicts >= maxClaimConflicts {
+				return
+			}
+			continue
+		}
+		conflicts = 0
 
-			// Check if at least 2 users exist before popping
-			userCount, err := w.userRepo.GetQueueLength(ctx)
+		// The pair is back in the queue; leave them to the next wake-up or tick
+		// rather than spinning on storage that is failing
+		if err := w.chatUsecase.HandleChatPair(ctx, *userA, *userB); err != nil {
+			log.Printf("❌ Failed to pair users %s & %s: %v", userA.UserID, userB.UserID, err)
+			return
+		}
+
+		metrics.MatchesTotal.Inc()
+		waitA, waitB := recordMatch(*userA), recordMatch(*userB)
+		// Both users already have read loops on the instances they are connected to
+		log.Printf("✅ Matched Users: %s <-> %s (waited %s / %s)", userA.UserID, userB.UserID, waitA, waitB)
+	}
+}
+
+// findPair picks the next two users to match, oldest first.
+// A user with interest tags is paired with the longest-waiting user sharing a tag.
+// Once they have waited longer than tagWait (or have no tags) they fall back to
+// the global FIFO and may pair with any other user that is also eligible for it.
+// Users who recently skipped each other are never paired.
+func (w *MatchmakingWorker) findPair(ctx context.Context) (*entity.User, *entity.User, error) {
+	users, err := w.userRepo.PeekQueue(ctx, scanLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range users {
+		userA := users[i]
+
+		if len(userA.Tags) > 0 {
+			partners, err := w.userRepo.FindTagPartners(ctx, userA, tagCandidates)
 			if err != nil {
-				log.Printf("❌ Error checking queue length: %v", err)
-				time.Sleep(5 * time.Second)
-				continue
-			}		
-			if userCount < 2 {
-				log.Println("⚠️ Not enough users in queue, waiting...")
-				time.Sleep(5 * time.Second)
-				continue
+				return nil, nil, err
+			}
+			for j := range partners {
+				ok, err := w.canPair(ctx, userA, partners[j])
+				if err != nil {
+					return nil, nil, err
+				}
+				if ok {
+					return &userA, &partners[j], nil
+				}
 			}
+		}
 
-			// Pop exactly 2 users
-			users, err := w.userRepo.PopTopUsers(ctx, 2)
-			if err != nil || len(users) != 2 {
-				log.Printf("❌ Error retrieving users from queue: %v", err)
-				time.Sleep(5 * time.Second)
+		if !w.fifoEligible(userA) {
+			continue
+		}
+		for j := i + 1; j < len(users); j++ {
+			if !w.fifoEligible(users[j]) {
 				continue
 			}
+			ok, err := w.canPair(ctx, userA, users[j])
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				return &userA, &users[j], nil
+			}
+		}
+	}
 
-			// Pair users
-			userA, userB := users[0], users[1]
+	return nil, nil, nil
+}
 
-			if err := w.chatUsecase.HandleChatPair(ctx, userA, userB); err != nil {
-				log.Printf("❌ Failed to pair users %s & %s: %v", userA.UserID, userB.UserID, err)
-				continue
-			}
+// canPair reports whether two users may be matched with each other.
+// Shadow-banned users are only ever matched among themselves.
+func (w *MatchmakingWorker) canPair(ctx context.Context, userA, userB entity.User) (bool, error) {
+	if userA.Shadow != userB.Shadow {
+		return false, nil
+	}
+	skipped, err := w.userRepo.IsSkipped(ctx, userA.UserID, userB.UserID)
+	if err != nil {
+		return false, err
+	}
+	return !skipped, nil
+}
+
+// fifoEligible reports whether a user may be matched without sharing a tag
+func (w *MatchmakingWorker) fifoEligible(user entity.User) bool {
+	return len(user.Tags) == 0 || time.Since(user.QueuedAt) >= w.tagWait
+}
 
-			log.Printf("✅ Matched Users: %s <-> %s", userA.UserID, userB.UserID)
-			w.chatUsecase.ListenFromConnection(userA.UserID)
-			w.chatUsecase.ListenFromConnection(userB.UserID)
+// recordMatch observes how long a matched user waited in the queue
+func recordMatch(user entity.User) time.Duration {
+	wait := time.Since(user.QueuedAt)
+	metrics.MatchWaitSeconds.Observe(wait.Seconds())
+	return wait
+}
 
-			// Sleep before next matchmaking check
-			time.Sleep(5 * time.Second)
-		}
+// CheckAlive fails unless the worker loop is running and has iterated within maxStall
+func (w *MatchmakingWorker) CheckAlive(maxStall time.Duration) error {
+	if !w.running.Load() {
+		return errors.New("matchmaking worker is not running")
+	}
+	if stalled := time.Since(time.Unix(0, w.lastLoop.Load())); stalled > maxStall {
+		return fmt.Errorf("matchmaking worker stuck for %s", stalled.Round(time.Second))
 	}
+	return nil
 }
+
+// touch records that the worker is making progress, for CheckAlive
+func (w *MatchmakingWorker) touch() {
+	w.lastLoop.Store(time.Now().UnixNano())
+}
+
 // Stop signals the matchmaking worker to terminate
 func (w *MatchmakingWorker) Stop() {
 	log.Println("🚀 Stopping Matchmaking Worker...")
 	close(w.stopChan) // Sends a stop signal
 }
-
@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/memory"
+	"github.com/royroki/LetsGo/internal/modules/chat/infrastructure/persistence"
+)
+
+// pairRecorder is a use case that records every pair handed to it
+type pairRecorder struct {
+	interfaces.ChatUseCase
+
+	mu    sync.Mutex
+	pairs map[string]int // userID -> number of chats they were placed in
+	total int
+}
+
+func (p *pairRecorder) ScreenQueuedUser(ctx context.Context, user entity.User) (bool, error) {
+	return true, nil
+}
+
+func (p *pairRecorder) HandleChatPair(ctx context.Context, userA, userB entity.User) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pairs[userA.UserID]++
+	p.pairs[userB.UserID]++
+	p.total += 2
+	return nil
+}
+
+func (p *pairRecorder) RecoverStaleClaims(ctx context.Context) error {
+	return nil
+}
+
+func (p *pairRecorder) matched() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.total
+}
+
+func TestConcurrentWorkersNeverMatchAUserTwice(t *testing.T) {
+	const (
+		workers = 8
+		users   = 200
+	)
+
+	server := miniredis.RunT(t)
+	recorder := &pairRecorder{pairs: make(map[string]int)}
+
+	for i := 0; i < workers; i++ {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		worker := NewMatchmakingWorker(recorder, persistence.NewUserRepository(client, "waiting_queue"), time.Second)
+		go worker.Run()
+		t.Cleanup(worker.Stop)
+	}
+
+	// Enqueue from several clients at once so workers race on a growing queue
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		repo := persistence.NewUserRepository(client, "waiting_queue")
+
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for n := offset; n < users; n += 4 {
+				user := entity.User{UserID: fmt.Sprintf("user-%03d", n), JoinTime: time.Now()}
+				if err := repo.AddUserToQueue(context.Background(), user); err != nil {
+					t.Errorf("enqueue %s: %v", user.UserID, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for recorder.matched() < users && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for userID, chats := range recorder.pairs {
+		if chats != 1 {
+			t.Errorf("user %s was placed in %d chats", userID, chats)
+		}
+	}
+	if recorder.total != users {
+		t.Errorf("matched %d users, want %d", recorder.total, users)
+	}
+}
+
+// failingPairs is a use case whose chats can never be created. Like the real
+// use case it puts the pair back in the queue, up to a limit so a spinning
+// worker still terminates.
+type failingPairs struct {
+	interfaces.ChatUseCase
+	users repository.UserRepository
+	calls int
+}
+
+func (f *failingPairs) ScreenQueuedUser(ctx context.Context, user entity.User) (bool, error) {
+	return true, nil
+}
+
+func (f *failingPairs) HandleChatPair(ctx context.Context, userA, userB entity.User) error {
+	if f.calls++; f.calls < 5 {
+		f.users.AddUserToQueue(ctx, userA)
+		f.users.AddUserToQueue(ctx, userB)
+	}
+	return errors.New("storage unavailable")
+}
+
+func TestDrainStopsOnPairingError(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	useCase := &failingPairs{users: users}
+	worker := NewMatchmakingWorker(useCase, users, time.Second)
+
+	for _, userID := range []string{"a", "b"} {
+		if err := users.AddUserToQueue(ctx, entity.User{UserID: userID, JoinTime: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	worker.drainQueue(ctx)
+
+	if useCase.calls != 1 {
+		t.Errorf("pairing attempted %d times in one pass, want 1", useCase.calls)
+	}
+	if length, _ := users.GetQueueLength(ctx); length != 2 {
+		t.Errorf("queue length = %d, want the pair left for the next pass", length)
+	}
+}
+
+func TestDrainCountsAsProgress(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepository()
+	worker := NewMatchmakingWorker(&pairRecorder{pairs: make(map[string]int)}, users, time.Second)
+	worker.running.Store(true)
+	// The pass started long ago and has been matching ever since
+	worker.lastLoop.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	for _, userID := range []string{"a", "b"} {
+		if err := users.AddUserToQueue(ctx, entity.User{UserID: userID, JoinTime: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	worker.drainQueue(ctx)
+
+	if err := worker.CheckAlive(time.Minute); err != nil {
+		t.Errorf("CheckAlive after draining = %v, want nil", err)
+	}
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	service "github.com/royroki/LetsGo/internal/modules/chat/domain/services"
+	"github.com/royroki/LetsGo/internal/modules/chat/presentation/middleware"
+)
+
+// Limits on listed reports and bans
+const (
+	defaultReportPage = 50
+	maxReportPage     = 500
+)
+
+// resolveRequest is the body of a report resolution
+type resolveRequest struct {
+	Action   entity.ReportAction `json:"action"`
+	Note     string              `json:"note"`
+	BanHours int                 `json:"ban_hours"` // 0 bans permanently
+}
+
+// banRequest is the body of a new ban
+type banRequest struct {
+	Kind   entity.BanKind `json:"kind"`  // user, ip or device
+	Mode   entity.BanMode `json:"mode"`  // block (default) or shadow
+	Value  string         `json:"value"` // User ID, IP address or CIDR range, or device fingerprint
+	Reason string         `json:"reason"`
+	Hours  int            `json:"hours"` // 0 bans permanently
+}
+
+// AdminController serves the moderation API
+type AdminController struct {
+	chatUseCase interfaces.ChatUseCase
+}
+
+// NewAdminController initializes the moderation API
+func NewAdminController(chatUseCase interfaces.ChatUseCase) *AdminController {
+	return &AdminController{chatUseCase: chatUseCase}
+}
+
+// HandleTranscript returns the decrypted transcript of a chat
+func (c *AdminController) HandleTranscript(w http.ResponseWriter, r *http.Request) {
+	chatID := mux.Vars(r)["chatID"]
+
+	entries, err := c.chatUseCase.GetTranscript(r.Context(), chatID)
+	switch {
+	case errors.Is(err, service.ErrTranscriptsDisabled):
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrTranscriptNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no transcript kept for this chat"})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read transcript"})
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{"chat_id": chatID, "messages": entries})
+	}
+}
+
+// HandleListReports lists reports by `?status=` (open by default), up to `?limit=`
+func (c *AdminController) HandleListReports(w http.ResponseWriter, r *http.Request) {
+	status := entity.ReportStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = entity.ReportStatusOpen
+	}
+	if status != entity.ReportStatusOpen && status != entity.ReportStatusResolved {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be open or resolved"})
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := c.chatUseCase.ListReports(r.Context(), status, limit)
+	if err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	if reports == nil {
+		reports = []entity.Report{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"reports": reports})
+}
+
+// HandleGetReport returns a single report
+func (c *AdminController) HandleGetReport(w http.ResponseWriter, r *http.Request) {
+	report, err := c.chatUseCase.GetReport(r.Context(), mux.Vars(r)["reportID"])
+	if err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// HandleResolveReport records a moderator's decision on a report
+func (c *AdminController) HandleResolveReport(w http.ResponseWriter, r *http.Request) {
+	var request resolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if request.BanHours < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ban_hours cannot be negative"})
+		return
+	}
+
+	report, err := c.chatUseCase.ResolveReport(r.Context(), mux.Vars(r)["reportID"], request.Action, request.Note, middleware.AdminActorFromContext(r.Context()), time.Duration(request.BanHours)*time.Hour)
+	if err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// HandleListBans lists active bans, newest first, up to `?limit=`
+func (c *AdminController) HandleListBans(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	bans, err := c.chatUseCase.ListBans(r.Context(), limit)
+	if err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	if bans == nil {
+		bans = []entity.Ban{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"bans": bans})
+}
+
+// HandleCreateBan bans a user, IP range or device on behalf of the calling operator
+func (c *AdminController) HandleCreateBan(w http.ResponseWriter, r *http.Request) {
+	var request banRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if request.Hours < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "hours cannot be negative"})
+		return
+	}
+	if request.Reason == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "reason is required"})
+		return
+	}
+
+	actor := middleware.AdminActorFromContext(r.Context())
+	ban, err := c.chatUseCase.CreateBan(r.Context(), request.Kind, request.Mode, request.Value, request.Reason, actor, time.Duration(request.Hours)*time.Hour)
+	if err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, ban)
+}
+
+// HandleLiftBan removes a ban before it expires
+func (c *AdminController) HandleLiftBan(w http.ResponseWriter, r *http.Request) {
+	if err := c.chatUseCase.LiftBan(r.Context(), mux.Vars(r)["banID"], middleware.AdminActorFromContext(r.Context())); err != nil {
+		c.writeModerationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLimit reads `?limit=`, writing a 400 and reporting false when it is invalid
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultReportPage, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+		return 0, false
+	}
+	return min(limit, maxReportPage), true
+}
+
+// writeModerationError maps moderation errors to HTTP responses
+func (c *AdminController) writeModerationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrModerationDisabled):
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAction):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "action must be dismiss or ban"})
+	case errors.Is(err, service.ErrInvalidBan):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrReportNotFound), errors.Is(err, repository.ErrBanNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrReportResolved):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "moderation request failed"})
+	}
+}
@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
+	service "github.com/royroki/LetsGo/internal/modules/chat/domain/services"
+	"github.com/royroki/LetsGo/internal/modules/chat/presentation/middleware"
 	web_socket "github.com/royroki/LetsGo/internal/modules/chat/presentation/websocket"
 )
 
@@ -22,3 +27,52 @@ func NewChatController(chatUseCase interfaces.ChatUseCase, wsHandler *web_socket
 func (c *ChatController) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	c.webSocketHandler.HandleWSConnection(w, r)
 }
+
+// HandleEvents streams the user's messages over Server-Sent Events
+func (c *ChatController) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	c.webSocketHandler.HandleEvents(w, r)
+}
+
+// HandleMessages accepts a message sent alongside an event stream
+func (c *ChatController) HandleMessages(w http.ResponseWriter, r *http.Request) {
+	c.webSocketHandler.HandleMessages(w, r)
+}
+
+// HandleSkip ends the user's current chat and puts both users back in the queue.
+// The caller must prove who they are with a JWT or, without auth, the user's session token.
+func (c *ChatController) HandleSkip(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	subject, ok := middleware.SubjectFromContext(r.Context())
+	if !ok {
+		// User IDs are shared with every partner, so knowing one proves nothing
+		sessionUserID, err := c.webSocketHandler.SessionUserID(r)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid session token"})
+			return
+		}
+		subject = sessionUserID
+	}
+	if subject != userID {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "cannot skip for another user"})
+		return
+	}
+
+	err := c.chatUseCase.SkipPartner(r.Context(), userID)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrNotInChat):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to skip partner"})
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "skipped"})
+	}
+}
+
+// writeJSON writes `body` as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/royroki/LetsGo/internal/common/health"
+)
+
+type HealthController struct {
+	readiness *health.Readiness
+}
+
+func NewHealthController(readiness *health.Readiness) *HealthController {
+	return &HealthController{readiness: readiness}
+}
+
+// HandleHealth reports that the process is alive
+func (c *HealthController) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// HandleReady reports whether this instance should receive traffic
+func (c *HealthController) HandleReady(w http.ResponseWriter, r *http.Request) {
+	report := c.readiness.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminActorHeader names the operator behind an admin request, since they all share one token
+const AdminActorHeader = "X-Admin-Actor"
+
+// maxAdminActorLength bounds the recorded operator name
+const maxAdminActorLength = 64
+
+const adminActorKey contextKey = "admin_actor"
+
+// NewAdminMiddleware admits only requests carrying `Authorization: Bearer <apiToken>`
+// and a non-empty X-Admin-Actor naming the operator, which is logged and recorded.
+// With an empty apiToken the admin API is disabled and every request is refused.
+func NewAdminMiddleware(apiToken string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiToken == "" {
+				writeError(w, http.StatusForbidden, "admin API is disabled")
+				return
+			}
+
+			bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(apiToken)) != 1 {
+				log.Printf("⚠️ Rejected admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+				writeError(w, http.StatusUnauthorized, "invalid admin token")
+				return
+			}
+
+			actor := strings.TrimSpace(r.Header.Get(AdminActorHeader))
+			if actor == "" || len(actor) > maxAdminActorLength {
+				writeError(w, http.StatusBadRequest, AdminActorHeader+" must name the operator")
+				return
+			}
+
+			log.Printf("🔐 Admin request %s %s by %s from %s", r.Method, r.URL.Path, actor, r.RemoteAddr)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminActorKey, actor)))
+		})
+	}
+}
+
+// AdminActorFromContext returns the operator behind an admin request
+func AdminActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(adminActorKey).(string)
+	return actor
+}
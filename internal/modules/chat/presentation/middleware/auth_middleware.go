@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/royroki/LetsGo/internal/common/token"
+)
+
+// AccessTokenParam is the query parameter carrying the access token
+const AccessTokenParam = "access_token"
+
+// AccessTokenSubprotocolPrefix marks the Sec-WebSocket-Protocol entry carrying the access token,
+// for browsers that cannot set headers on the upgrade: `access_token.<jwt>`
+const AccessTokenSubprotocolPrefix = "access_token."
+
+type contextKey string
+
+const subjectKey contextKey = "auth_subject"
+
+// NewAuthMiddleware rejects requests without a valid HS256 JWT and stores its subject
+// in the request context. With a nil verifier every request passes anonymously.
+func NewAuthMiddleware(verifier *token.JWTVerifier) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if verifier == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := accessToken(r)
+			if raw == "" {
+				writeError(w, http.StatusUnauthorized, "missing access token")
+				return
+			}
+
+			claims, err := verifier.Verify(raw)
+			if err != nil {
+				log.Printf("⚠️ Rejected access token from %s: %v", r.RemoteAddr, err)
+				writeError(w, http.StatusUnauthorized, "invalid access token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), subjectKey, claims.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SubjectFromContext returns the authenticated subject, if the request was authenticated
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey).(string)
+	return subject, ok && subject != ""
+}
+
+// accessToken extracts the token from the Authorization header, the query string
+// or the Sec-WebSocket-Protocol header, in that order
+func accessToken(r *http.Request) string {
+	if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(bearer)
+	}
+	if raw := r.URL.Query().Get(AccessTokenParam); raw != "" {
+		return raw
+	}
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			if raw, found := strings.CutPrefix(strings.TrimSpace(protocol), AccessTokenSubprotocolPrefix); found {
+				return raw
+			}
+		}
+	}
+	return ""
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that made the request.
+// Behind `trustedHops` proxies, each appending to X-Forwarded-For, the entry added by the
+// outermost trusted proxy is used: anything to its left was written by the client and may be
+// forged. With 0 hops, or a header shorter than the proxy chain, the peer address is used.
+func ClientIP(r *http.Request, trustedHops int) string {
+	if trustedHops > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if len(entries) >= trustedHops {
+			if ip := strings.TrimSpace(entries[len(entries)-trustedHops]); net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		hops      int
+		want      string
+	}{
+		{"headers ignored without trusted hops", []string{"198.51.100.7"}, 0, "192.0.2.1"},
+		{"no header falls back to the peer", nil, 1, "192.0.2.1"},
+		{"one proxy uses the rightmost entry", []string{"203.0.113.9, 198.51.100.7"}, 1, "198.51.100.7"},
+		{"a forged leftmost entry is ignored", []string{"10.0.0.1, 198.51.100.7"}, 1, "198.51.100.7"},
+		{"two proxies skip the inner one", []string{"10.0.0.1, 198.51.100.7, 172.16.0.2"}, 2, "198.51.100.7"},
+		{"repeated headers form one list", []string{"10.0.0.1", "198.51.100.7"}, 1, "198.51.100.7"},
+		{"a chain shorter than the hops uses the peer", []string{"198.51.100.7"}, 2, "192.0.2.1"},
+		{"an unparsable entry uses the peer", []string{"10.0.0.1, not-an-ip"}, 1, "192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/ws", nil)
+			r.RemoteAddr = "192.0.2.1:54321"
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(r, test.hops); got != test.want {
+				t.Errorf("ClientIP = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,44 @@ package router
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/royroki/LetsGo/internal/common/metrics"
 	"github.com/royroki/LetsGo/internal/modules/chat/presentation/controller"
 )
 
-func SetupChatRouter(chatController *controller.ChatController) *mux.Router {
+func SetupChatRouter(chatController *controller.ChatController, healthController *controller.HealthController, adminController *controller.AdminController, authMiddleware, adminMiddleware mux.MiddlewareFunc) *mux.Router {
 	router := mux.NewRouter()
 
+	// Liveness and readiness probes
+	router.HandleFunc("/healthz", healthController.HandleHealth).Methods("GET")
+	router.HandleFunc("/readyz", healthController.HandleReady).Methods("GET")
+
+	// Prometheus scrape endpoint
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Routes acting on behalf of a user require a valid access token
+	userRoutes := router.NewRoute().Subrouter()
+	userRoutes.Use(authMiddleware)
+
 	// WebSocket route for chat
-	router.HandleFunc("/ws", chatController.HandleConnection).Methods("GET")
+	userRoutes.HandleFunc("/ws", chatController.HandleConnection).Methods("GET")
+
+	// Server-Sent Events and HTTP POST fallback for networks that block WebSocket upgrades
+	userRoutes.HandleFunc("/events", chatController.HandleEvents).Methods("GET")
+	userRoutes.HandleFunc("/messages", chatController.HandleMessages).Methods("POST")
+
+	// Skip the current partner without dropping the connection
+	userRoutes.HandleFunc("/users/{userID}/skip", chatController.HandleSkip).Methods("POST")
+
+	// Moderation API for operators holding the admin token
+	adminRoutes := router.PathPrefix("/admin").Subrouter()
+	adminRoutes.Use(adminMiddleware)
+	adminRoutes.HandleFunc("/chats/{chatID}/transcript", adminController.HandleTranscript).Methods("GET")
+	adminRoutes.HandleFunc("/reports", adminController.HandleListReports).Methods("GET")
+	adminRoutes.HandleFunc("/reports/{reportID}", adminController.HandleGetReport).Methods("GET")
+	adminRoutes.HandleFunc("/reports/{reportID}/resolve", adminController.HandleResolveReport).Methods("POST")
+	adminRoutes.HandleFunc("/bans", adminController.HandleListBans).Methods("GET")
+	adminRoutes.HandleFunc("/bans", adminController.HandleCreateBan).Methods("POST")
+	adminRoutes.HandleFunc("/bans/{banID}", adminController.HandleLiftBan).Methods("DELETE")
 
 	return router
 }
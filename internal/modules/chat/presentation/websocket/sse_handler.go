@@ -0,0 +1,86 @@
+package web_socket
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
+	web_socket "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+	"github.com/royroki/LetsGo/internal/modules/chat/presentation/middleware"
+)
+
+// SessionTokenHeader carries the token from the `session` message on `POST /messages`
+const SessionTokenHeader = "X-Session-Token"
+
+// HandleEvents streams server messages as Server-Sent Events for clients whose network blocks
+// WebSocket upgrades. It admits, resumes and matches users exactly like HandleWSConnection.
+func (h *WebSocketHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	admitted, ok := h.admit(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := web_socket.NewSSEConnection(w, r)
+	if err != nil {
+		log.Printf("Event stream error: %v", err)
+		if admitted.resumed {
+			h.useCase.EndChatSession(r.Context(), admitted.userID) // The claimed session has no connection to go to
+		}
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "event streams are not supported")
+		return
+	}
+	defer conn.Close() // Nothing may write to the response once the handler returns
+
+	h.serve(r, admitted, conn)
+
+	// The read loop runs in the background; keep the response open until the stream ends
+	select {
+	case <-conn.Done():
+	case <-r.Context().Done():
+	}
+}
+
+// SessionUserID returns the user named by the request's session token
+func (h *WebSocketHandler) SessionUserID(r *http.Request) (string, error) {
+	return h.resumeTokens.Verify(r.Header.Get(SessionTokenHeader))
+}
+
+// HandleMessages accepts one client message for the event stream named by the session token.
+// The request must reach the instance holding that stream.
+func (h *WebSocketHandler) HandleMessages(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.SessionUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid_session", "missing or invalid session token")
+		return
+	}
+	if subject, ok := middleware.SubjectFromContext(r.Context()); ok && subject != userID {
+		writeError(w, http.StatusForbidden, "forbidden", "session token does not belong to this user")
+		return
+	}
+
+	conn, ok := h.wsHub.GetConnection(userID).(*web_socket.SSEConnection)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no_event_stream", "no event stream for this session on this instance")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxMessage))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "too_large", "message too large")
+		return
+	}
+
+	err = conn.Deliver(r.Context(), data)
+	switch {
+	case errors.Is(err, repository.ErrInvalidMessage):
+		writeError(w, http.StatusBadRequest, "invalid_message", "message is not a valid envelope")
+	case errors.Is(err, web_socket.ErrSSEClosed):
+		writeError(w, http.StatusGone, "stream_closed", "the event stream has ended")
+	case err != nil:
+		writeError(w, http.StatusServiceUnavailable, "not_delivered", "message could not be delivered")
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
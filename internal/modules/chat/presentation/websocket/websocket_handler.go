@@ -1,55 +1,277 @@
 package web_socket
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/royroki/LetsGo/internal/common/health"
+	"github.com/royroki/LetsGo/internal/common/token"
 	"github.com/royroki/LetsGo/internal/modules/chat/application/interfaces"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/entity"
+	"github.com/royroki/LetsGo/internal/modules/chat/domain/repository"
 	web_socket "github.com/royroki/LetsGo/internal/modules/chat/infrastructure/websocket"
+	"github.com/royroki/LetsGo/internal/modules/chat/presentation/middleware"
 )
 
+// HandlerConfig holds the optional settings of a WebSocketHandler
+type HandlerConfig struct {
+	ResumeTokens     *token.Signer // Signs and verifies session resume tokens
+	ResumeTokenTTL   time.Duration
+	AllowedOrigins   []string          // Origins allowed to upgrade; empty or "*" allows any
+	TrustedProxyHops int               // Proxies whose X-Forwarded-For entries are trusted; 0 uses the peer address
+	Readiness        *health.Readiness // Upgrades are refused once it is draining
+	MaxMessageBytes  int64             // Largest client frame or posted message; 0 uses DefaultMaxMessageBytes
+	Connection       web_socket.ConnectionConfig
+}
+
+// DefaultMaxMessageBytes bounds a single client message when no limit is configured
+const DefaultMaxMessageBytes = 64 << 10
+
+// maxDeviceIDLength bounds the device fingerprint a client may present
+const maxDeviceIDLength = 128
+
+// admission is a client let in by admit
+type admission struct {
+	userID  string
+	resumed bool // Reattaching to a suspended session
+	client  entity.ClientInfo
+}
+
 // WebSocketHub manages active WebSocket connections.
 type WebSocketHandler struct {
-	useCase  interfaces.ChatUseCase
-	upgrader websocket.Upgrader
-	wsHub    *web_socket.WebSocketHub
+	useCase        interfaces.ChatUseCase
+	upgrader       websocket.Upgrader
+	wsHub          repository.WebSocketRepository
+	resumeTokens   *token.Signer
+	resumeTokenTTL time.Duration
+	trustedHops    int
+	readiness      *health.Readiness
+	maxMessage     int64
+	connConfig     web_socket.ConnectionConfig
 }
 
 // NewWebSocketHub initializes WebSocketHub with the chat use case.
-func NewWebSocketHandler(useCase interfaces.ChatUseCase, hub *web_socket.WebSocketHub) *WebSocketHandler {
+func NewWebSocketHandler(useCase interfaces.ChatUseCase, hub repository.WebSocketRepository, config HandlerConfig) *WebSocketHandler {
+	if config.MaxMessageBytes <= 0 {
+		config.MaxMessageBytes = DefaultMaxMessageBytes
+	}
 	return &WebSocketHandler{
 		useCase: useCase,
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
-			CheckOrigin:     func(r *http.Request) bool { return true },
+			CheckOrigin:     originChecker(config.AllowedOrigins),
+			// Subprotocols are picked by selectSubprotocol, which can also echo an access token
 		},
-		wsHub: hub,
+		wsHub:          hub,
+		resumeTokens:   config.ResumeTokens,
+		resumeTokenTTL: config.ResumeTokenTTL,
+		trustedHops:    config.TrustedProxyHops,
+		readiness:      config.Readiness,
+		maxMessage:     config.MaxMessageBytes,
+		connConfig:     config.Connection,
 	}
 }
 
 // HandleWSConnection upgrades the HTTP request to WebSocket and handles the connection lifecycle.
+// An authenticated subject becomes the userID; anonymous clients get a random one.
+// A client that presents a valid `?resume=<token>` within the grace period reattaches to its previous session.
+// Banned users, IPs and devices are refused before the upgrade.
 func (h *WebSocketHandler) HandleWSConnection(w http.ResponseWriter, r *http.Request) {
-	conn, err := h.upgrader.Upgrade(w, r, nil)
+	admitted, ok := h.admit(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, selectSubprotocol(r))
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
+		if admitted.resumed {
+			h.useCase.EndChatSession(r.Context(), admitted.userID) // The claimed session has no connection to go to
+		}
 		return
 	}
+	// Frames are buffered whole before any limit or filter sees them, so refuse oversized ones here
+	conn.SetReadLimit(h.maxMessage)
+
+	h.serve(r, admitted, web_socket.NewGorillaConnection(conn, h.connConfig))
+}
+
+// admit decides whether a client may connect and which user it connects as.
+// It writes the rejection itself and reports false when the client is turned away.
+func (h *WebSocketHandler) admit(w http.ResponseWriter, r *http.Request) (admission, bool) {
+	if h.readiness != nil && h.readiness.Draining() {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "draining", "server restarting, reconnect")
+		return admission{}, false
+	}
+
+	client := entity.ClientInfo{IP: middleware.ClientIP(r, h.trustedHops), DeviceID: deviceID(r)}
+	if !h.useCase.AllowConnection(r.Context(), client.IP) {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusTooManyRequests, entity.ErrCodeRateLimited, "too many connection attempts")
+		return admission{}, false
+	}
 
-	// Generate connID and extract userID
-	userID := uuid.New().String()
+	subject, authenticated := middleware.SubjectFromContext(r.Context())
+	// Shadow-banned clients are let in as usual; matchmaking keeps them apart
+	if ban := h.useCase.FindBan(r.Context(), subject, client); ban != nil && !ban.Shadow() {
+		log.Printf("🔨 Refused banned client %s (%s ban %s)", client.IP, ban.Kind, ban.ID)
+		writeError(w, http.StatusForbidden, entity.ErrCodeBanned, banMessage(ban))
+		return admission{}, false
+	}
+
+	// Resume an earlier session, or start a new one
+	userID, resumed := h.resumeUserID(r)
+	switch {
+	case resumed && authenticated && userID != subject:
+		log.Printf("⚠️ Resume token for %s presented by %s", userID, subject)
+		h.useCase.EndChatSession(r.Context(), userID) // The claimed session cannot be handed to someone else
+		writeError(w, http.StatusForbidden, "forbidden", "resume token does not belong to this user")
+		return admission{}, false
+	case resumed:
+	case authenticated:
+		if h.useCase.IsUserActive(r.Context(), subject) {
+			writeError(w, http.StatusConflict, "already_connected", "user already connected")
+			return admission{}, false
+		}
+		userID = subject
+	default:
+		userID = uuid.New().String()
+	}
+	return admission{userID: userID, resumed: resumed, client: client}, true
+}
+
+// serve registers an accepted connection with the hub and reads from it until it ends
+func (h *WebSocketHandler) serve(r *http.Request, admitted admission, conn repository.Connection) {
+	userID := admitted.userID
 
 	// Add the new connection to ws hub
 	h.wsHub.AddConnection(userID, conn)
 
+	// Hand out the token that lets this connection be resumed
+	h.wsHub.SendMessage(userID, entity.NewSessionMessage(userID, h.resumeTokens.Sign(userID, h.resumeTokenTTL), "🔑 Session "+userID))
+
 	// Inform use case of new connection
-	err = h.useCase.HandleNewConnection(r.Context(), userID)
+	var err error
+	if admitted.resumed {
+		err = h.useCase.HandleResumedConnection(r.Context(), userID)
+	} else {
+		err = h.useCase.HandleNewConnection(r.Context(), userID, admitted.client, parseTags(r))
+	}
 	if err != nil {
 		log.Printf("Error connecting user: %v", err)
 		h.wsHub.RemoveConnection(userID)
 		return
 	}
 
+	// Read from the connection for its whole lifetime, across chats
+	h.useCase.ListenFromConnection(userID)
+}
+
+// resumeUserID verifies a resume token and claims the suspended session it names
+func (h *WebSocketHandler) resumeUserID(r *http.Request) (string, bool) {
+	resumeToken := r.URL.Query().Get("resume")
+	if resumeToken == "" {
+		return "", false
+	}
+
+	userID, err := h.resumeTokens.Verify(resumeToken)
+	if err != nil {
+		log.Printf("⚠️ Rejected resume token: %v", err)
+		return "", false
+	}
+	if err := h.useCase.ResumeSession(r.Context(), userID); err != nil {
+		log.Printf("⚠️ Cannot resume session for %s: %v", userID, err)
+		return "", false
+	}
+	return userID, true
+}
+
+// selectSubprotocol returns the response header naming the subprotocol to use: the first one
+// offered that the server speaks, else an offered access token, since a browser that asked
+// for subprotocols aborts a handshake that echoes none. Without either, nothing is negotiated.
+func selectSubprotocol(r *http.Request) http.Header {
+	offered := websocket.Subprotocols(r)
+	for _, supported := range web_socket.Subprotocols {
+		for _, protocol := range offered {
+			if protocol == supported {
+				return http.Header{"Sec-Websocket-Protocol": {protocol}}
+			}
+		}
+	}
+	for _, protocol := range offered {
+		if strings.HasPrefix(protocol, middleware.AccessTokenSubprotocolPrefix) {
+			return http.Header{"Sec-Websocket-Protocol": {protocol}}
+		}
+	}
+	return nil
+}
+
+// originChecker allows upgrades only from the configured origins
+func originChecker(allowed []string) func(r *http.Request) bool {
+	origins := make(map[string]struct{}, len(allowed))
+	for _, origin := range allowed {
+		if origin == "*" {
+			return func(r *http.Request) bool { return true }
+		}
+		origins[strings.TrimRight(origin, "/")] = struct{}{}
+	}
+	if len(origins) == 0 {
+		return func(r *http.Request) bool { return true }
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true // Not a browser; browsers always send Origin on upgrades
+		}
+		if _, ok := origins[origin]; ok {
+			return true
+		}
+		log.Printf("⚠️ Rejected WebSocket origin %q", origin)
+		return false
+	}
+}
+
+// writeError rejects a request before the upgrade with a JSON error
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "message": message})
+}
+
+// deviceID reads the client's device fingerprint from `?device=` or X-Device-ID, ignoring oversized ones
+func deviceID(r *http.Request) string {
+	device := r.URL.Query().Get("device")
+	if device == "" {
+		device = r.Header.Get("X-Device-ID")
+	}
+	if len(device) > maxDeviceIDLength {
+		return ""
+	}
+	return device
+}
+
+// banMessage tells a banned client how long their ban lasts
+func banMessage(ban *entity.Ban) string {
+	if ban.ExpiresAt == nil {
+		return "banned permanently"
+	}
+	return fmt.Sprintf("banned until %s", ban.ExpiresAt.Format(time.RFC3339))
+}
+
+// parseTags reads interest tags from `?tags=a,b` (the parameter may also be repeated).
+func parseTags(r *http.Request) []string {
+	var tags []string
+	for _, value := range r.URL.Query()["tags"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	return tags
 }
@@ -0,0 +1,32 @@
+package web_socket
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSelectSubprotocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		offered string
+		want    string
+	}{
+		{"nothing offered", "", ""},
+		{"JSON preferred over raw text", "letsgo.text, letsgo.v1.json", "letsgo.v1.json"},
+		{"JSON alongside a token", "access_token.abc, letsgo.v1.json", "letsgo.v1.json"},
+		{"only a token", "access_token.abc", "access_token.abc"},
+		{"unknown only", "chat.v9", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/ws", nil)
+			if test.offered != "" {
+				r.Header.Set("Sec-WebSocket-Protocol", test.offered)
+			}
+			if got := selectSubprotocol(r).Get("Sec-WebSocket-Protocol"); got != test.want {
+				t.Errorf("selected %q, want %q", got, test.want)
+			}
+		})
+	}
+}